@@ -0,0 +1,249 @@
+package build
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// defaultAdmonitionTypes is admonition types that used when there are no
+// admonition types specified in metadata.
+var defaultAdmonitionTypes = []string{"note", "tip", "info", "warning", "danger"}
+
+var (
+	rxAdmonition = regexp.MustCompile(`^[ \t]*([A-Za-z](?:[\w-]*\w)?)([+-]?)(?:[ \t]+(.*?))?[ \t]*\r?\n?$`)
+
+	kindAdmonition      = ast.NewNodeKind("Admonition")
+	kindAdmonitionTitle = ast.NewNodeKind("AdmonitionTitle")
+)
+
+// admonitionNode is a container block for note, warning and other callout
+// boxes. Its first child is always the title.
+type admonitionNode struct {
+	ast.BaseBlock
+	AdmonitionType string
+	Collapsible    bool
+	Open           bool
+	fenceLength    int
+}
+
+// Kind implements ast.Node.
+func (n *admonitionNode) Kind() ast.NodeKind {
+	return kindAdmonition
+}
+
+// Dump implements ast.Node.
+func (n *admonitionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Type": n.AdmonitionType}, nil)
+}
+
+// admonitionTitleNode is the title of admonition. If it doesn't have any
+// children, the admonition type will be used as title.
+type admonitionTitleNode struct {
+	ast.BaseBlock
+}
+
+// Kind implements ast.Node.
+func (n *admonitionTitleNode) Kind() ast.NodeKind {
+	return kindAdmonitionTitle
+}
+
+// Dump implements ast.Node.
+func (n *admonitionTitleNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// admonitionExtension is goldmark extension for admonition container, e.g. :
+//
+//	:::warning Be careful
+//	Content with **markdown**.
+//	:::
+//
+// Type suffixed by `+` or `-` makes a collapsible admonition that open or
+// closed by default. To nest admonitions, the outer one must use more colons.
+type admonitionExtension struct {
+	types []string
+}
+
+// Extend implements goldmark.Extender.
+func (e *admonitionExtension) Extend(m goldmark.Markdown) {
+	types := make(map[string]struct{})
+	for _, t := range e.types {
+		types[strings.ToLower(t)] = struct{}{}
+	}
+
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(&admonitionParser{types: types}, 100)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&admonitionRenderer{}, 500)))
+}
+
+// admonitionParser is block parser for admonition.
+type admonitionParser struct {
+	types map[string]struct{}
+}
+
+// Trigger implements parser.BlockParser.
+func (b *admonitionParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+// Open implements parser.BlockParser.
+func (b *admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || pc.BlockIndent() > 3 {
+		return nil, parser.NoChildren
+	}
+
+	// Opening fence must have at least three colons, followed by known type
+	i := pos
+	for i < len(line) && line[i] == ':' {
+		i++
+	}
+
+	fenceLength := i - pos
+	if fenceLength < 3 {
+		return nil, parser.NoChildren
+	}
+
+	match := rxAdmonition.FindSubmatchIndex(line[i:])
+	if match == nil {
+		return nil, parser.NoChildren
+	}
+
+	admonitionType := strings.ToLower(string(line[i+match[2] : i+match[3]]))
+	if _, known := b.types[admonitionType]; !known {
+		return nil, parser.NoChildren
+	}
+
+	node := &admonitionNode{
+		AdmonitionType: admonitionType,
+		fenceLength:    fenceLength,
+	}
+
+	switch string(line[i+match[4] : i+match[5]]) {
+	case "+":
+		node.Collapsible = true
+		node.Open = true
+	case "-":
+		node.Collapsible = true
+	}
+
+	// Title is parsed as inline markdown later, along with the other blocks
+	title := &admonitionTitleNode{}
+	if match[6] >= 0 && match[7] > match[6] {
+		title.Lines().Append(text.NewSegment(
+			segment.Start+i+match[6],
+			segment.Start+i+match[7]))
+	}
+	node.AppendChild(node, title)
+
+	reader.Advance(segment.Len() - newlineLength(line) + segment.Padding)
+	return node, parser.HasChildren
+}
+
+// Continue implements parser.BlockParser.
+func (b *admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*admonitionNode)
+	line, segment := reader.PeekLine()
+
+	// Check if this line is the closing fence
+	w, pos := util.IndentWidth(line, reader.LineOffset())
+	if w < 4 {
+		i := pos
+		for i < len(line) && line[i] == ':' {
+			i++
+		}
+
+		if i-pos >= n.fenceLength && util.IsBlank(line[i:]) {
+			reader.Advance(segment.Len() - newlineLength(line) + segment.Padding)
+			return parser.Close
+		}
+	}
+
+	return parser.Continue | parser.HasChildren
+}
+
+// Close implements parser.BlockParser.
+func (b *admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+// CanInterruptParagraph implements parser.BlockParser.
+func (b *admonitionParser) CanInterruptParagraph() bool {
+	return true
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser.
+func (b *admonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// admonitionRenderer renders admonition as `<aside>`, or as `<details>` if
+// it's collapsible. Both has class `admonition` and `admonition-<type>`.
+type admonitionRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *admonitionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindAdmonition, r.renderAdmonition)
+	reg.Register(kindAdmonitionTitle, r.renderAdmonitionTitle)
+}
+
+func (r *admonitionRenderer) renderAdmonition(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*admonitionNode)
+	tag := "aside"
+	if n.Collapsible {
+		tag = "details"
+	}
+
+	if !entering {
+		w.WriteString("</" + tag + ">\n")
+		return ast.WalkContinue, nil
+	}
+
+	w.WriteString("<" + tag + ` class="admonition admonition-` + n.AdmonitionType + `"`)
+	if n.Open {
+		w.WriteString(" open")
+	}
+	w.WriteString(">\n")
+
+	return ast.WalkContinue, nil
+}
+
+func (r *admonitionRenderer) renderAdmonitionTitle(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	tag := "p"
+	parent, _ := node.Parent().(*admonitionNode)
+	if parent != nil && parent.Collapsible {
+		tag = "summary"
+	}
+
+	if !entering {
+		w.WriteString("</" + tag + ">\n")
+		return ast.WalkContinue, nil
+	}
+
+	w.WriteString("<" + tag + ` class="admonition-title">`)
+	if !node.HasChildren() && parent != nil {
+		defaultTitle := strings.ToUpper(parent.AdmonitionType[:1]) + parent.AdmonitionType[1:]
+		w.Write(util.EscapeHTML([]byte(defaultTitle)))
+	}
+
+	return ast.WalkContinue, nil
+}
+
+// newlineLength returns length of the newline at the end of line.
+func newlineLength(line []byte) int {
+	switch {
+	case len(line) >= 2 && line[len(line)-2] == '\r' && line[len(line)-1] == '\n':
+		return 2
+	case len(line) >= 1 && line[len(line)-1] == '\n':
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,278 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	fp "path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// rxArchiveURL matches URL path of archive, e.g. blog/2023, blog/2023/2,
+// blog/2023/03 and blog/2023/03/2. Like the other pages, page number is put
+// after the archive's URL. Month is always written in two digits, so page 1
+// to 9 of yearly archive are not mistaken as month.
+var rxArchiveURL = regexp.MustCompile(`^(?:(.+)/)?(\d{4})(?:/(0[1-9]|1[0-2]))?(?:/(\d+))?$`)
+
+// archiveOf parses URL path of an archive page, e.g. blog/2023 or
+// blog/2023/03/2, into the directory, year, month and page number. Month is
+// zero for yearly archive. It only succeed if the directory has archive
+// enabled, and there are no real content in that path, i.e. content named
+// like the year or page of the directory with the same number.
+func (wk *Worker) archiveOf(urlPath string) (dirURLPath string, year int, month int, pageNumber int, ok bool) {
+	parts := rxArchiveURL.FindStringSubmatch(urlPath)
+	if parts == nil {
+		return
+	}
+
+	// Parse the period
+	dirURLPath = parts[1]
+	if dirURLPath == "" {
+		dirURLPath = "."
+	}
+
+	year, _ = strconv.Atoi(parts[2])
+	month, _ = strconv.Atoi(parts[3])
+	pageNumber = 1
+	if parts[4] != "" {
+		pageNumber, _ = strconv.Atoi(parts[4])
+	}
+
+	// Real content always wins over the archive
+	yearPath := fp.Join(wk.ContentDir, dirURLPath, parts[2])
+	if fileutils.IsDir(yearPath) || fileutils.IsFile(yearPath+".md") {
+		return
+	}
+
+	// Make sure the directory has archive enabled
+	indexMdPath := fp.Join(wk.ContentDir, dirURLPath, "_index.md")
+	if !fileutils.IsFile(indexMdPath) {
+		return
+	}
+
+	meta, _, err := wk.parsePath(indexMdPath)
+	if err != nil || !meta.Archive {
+		return
+	}
+
+	// Year alone might be page number of the directory as well
+	if parts[3] == "" && parts[4] == "" && wk.dirMightHavePage(dirURLPath, meta.Pagination, year) {
+		return
+	}
+
+	ok = true
+	return
+}
+
+// dirMightHavePage checks whether the directory in URL path might have the
+// page number. Since counting the listed items requires parsing all of them,
+// every entry in the directory is counted instead, which is the upper limit.
+func (wk *Worker) dirMightHavePage(dirURLPath string, pageSize int, pageNumber int) bool {
+	if pageSize <= 0 {
+		return pageNumber == 1
+	}
+
+	entries, err := os.ReadDir(fp.Join(wk.ContentDir, dirURLPath))
+	if err != nil {
+		return false
+	}
+
+	maxPage := (len(entries) + pageSize - 1) / pageSize
+	return pageNumber <= maxPage
+}
+
+// isArchiveURL checks whether the URL path points to an archive page.
+func (wk *Worker) isArchiveURL(urlPath string) bool {
+	_, _, _, _, ok := wk.archiveOf(urlPath)
+	return ok
+}
+
+// archiveGroups groups files by the year and month of its creation time,
+// sorted from the newest one. Files without creation time are skipped.
+func archiveGroups(dirURLPath string, files []model.ContentPath) []model.ArchiveGroup {
+	// Sort files from the newest one
+	files = append([]model.ContentPath{}, files...)
+	sort.SliceStable(files, func(a, b int) bool {
+		return files[a].CreateTime.After(files[b].CreateTime)
+	})
+
+	// Group the files
+	var years []model.ArchiveGroup
+	for _, file := range files {
+		if file.CreateTime.IsZero() {
+			continue
+		}
+
+		year, month := file.CreateTime.Year(), int(file.CreateTime.Month())
+		if nYear := len(years); nYear == 0 || years[nYear-1].Year != year {
+			years = append(years, newArchiveGroup(dirURLPath, year, 0))
+		}
+
+		yearGroup := &years[len(years)-1]
+		if nMonth := len(yearGroup.Months); nMonth == 0 || yearGroup.Months[nMonth-1].Month != month {
+			yearGroup.Months = append(yearGroup.Months, newArchiveGroup(dirURLPath, year, month))
+		}
+
+		monthGroup := &yearGroup.Months[len(yearGroup.Months)-1]
+		yearGroup.Files = append(yearGroup.Files, file)
+		monthGroup.Files = append(monthGroup.Files, file)
+	}
+
+	return years
+}
+
+// newArchiveGroup creates an empty archive group for the period. Month is
+// zero for yearly group.
+func newArchiveGroup(dirURLPath string, year int, month int) model.ArchiveGroup {
+	group := model.ArchiveGroup{
+		URLPath: path.Join("/", dirURLPath, strconv.Itoa(year)),
+		Title:   strconv.Itoa(year),
+		Year:    year,
+		Month:   month,
+	}
+
+	if month > 0 {
+		group.URLPath = path.Join(group.URLPath, fmt.Sprintf("%02d", month))
+		group.Title = time.Month(month).String() + " " + group.Title
+	}
+
+	return group
+}
+
+// buildArchive builds list of files that created within a year or a month.
+// Both are paginated, and the files in yearly archive are grouped by month.
+func (wk *Worker) buildArchive(urlPath string, w io.Writer) ([]string, error) {
+	// Parse URL path
+	dirURLPath, year, month, pageNumber, ok := wk.archiveOf(urlPath)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an archive page", urlPath)
+	}
+
+	// Parse metadata
+	meta, _, err := wk.parsePath(fp.Join(wk.ContentDir, dirURLPath, "_index.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch all files in the directory, then group it by its period
+	allFiles, err := wk.listPages(dirURLPath, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the active period, along with the older and newer one
+	var periods []model.ArchiveGroup
+	for _, yearGroup := range archiveGroups(dirURLPath, allFiles) {
+		if month == 0 {
+			periods = append(periods, yearGroup)
+		} else {
+			periods = append(periods, yearGroup.Months...)
+		}
+	}
+
+	activePeriod := newArchiveGroup(dirURLPath, year, month)
+	var prevPeriod, nextPeriod model.ContentPath
+	for i, period := range periods {
+		if period.Year != year || period.Month != month {
+			continue
+		}
+
+		activePeriod = period
+		if i > 0 {
+			nextPeriod = model.ContentPath{URLPath: periods[i-1].URLPath, Title: periods[i-1].Title}
+		}
+
+		if i < len(periods)-1 {
+			prevPeriod = model.ContentPath{URLPath: periods[i+1].URLPath, Title: periods[i+1].Title}
+		}
+		break
+	}
+
+	// Period without any file doesn't have archive
+	if len(activePeriod.Files) == 0 {
+		return nil, fmt.Errorf("%s: %w", urlPath, ErrNotFound)
+	}
+
+	// Create template data
+	tplData := model.ArchiveData{
+		URLPath:    path.Join("/", urlPath),
+		Title:      meta.Title,
+		Period:     activePeriod.Title,
+		Year:       year,
+		Month:      month,
+		PrevPeriod: prevPeriod,
+		NextPeriod: nextPeriod,
+		PageSize:   meta.Pagination,
+	}
+
+	// Create path trails
+	trailSegments := strings.Split(dirURLPath, "/")
+	if dirURLPath != "." && len(trailSegments) > 0 {
+		trailSegments = append([]string{"."}, trailSegments...)
+	}
+
+	for i := 1; i <= len(trailSegments); i++ {
+		parentPath := strings.Join(trailSegments[:i], "/")
+		parentFilePath := fp.Join(wk.ContentDir, parentPath, "_index.md")
+		parentMeta, _, err := wk.parsePath(parentFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		tplData.PathTrails = append(tplData.PathTrails, model.ContentPath{
+			URLPath: path.Join("/", parentPath),
+			Title:   parentMeta.Title,
+			IsDir:   true,
+		})
+	}
+
+	yearGroup := newArchiveGroup(dirURLPath, year, 0)
+	tplData.PathTrails = append(tplData.PathTrails, model.ContentPath{
+		URLPath: yearGroup.URLPath,
+		Title:   yearGroup.Title,
+	})
+
+	if month > 0 {
+		tplData.PathTrails = append(tplData.PathTrails, model.ContentPath{
+			URLPath: activePeriod.URLPath,
+			Title:   time.Month(month).String(),
+		})
+	}
+
+	// Calculate pagination stuffs. For yearly archive, only files in the
+	// current page are grouped by month.
+	files := activePeriod.Files
+	page := paginate(len(files), tplData.PageSize, pageNumber)
+	tplData.CurrentPage = page.CurrentPage
+	tplData.MaxPage = page.MaxPage
+	tplData.Files = files[page.Start:page.End]
+
+	if month == 0 {
+		tplData.Months = archiveGroups(dirURLPath, tplData.Files)[0].Months
+	}
+
+	// Create child URLs
+	childURLs := []string{}
+	for _, monthGroup := range activePeriod.Months {
+		childURLs = append(childURLs, strings.TrimPrefix(monthGroup.URLPath, "/"))
+	}
+
+	pageBaseURL := strings.TrimPrefix(activePeriod.URLPath, "/")
+	childURLs = append(childURLs, pageURLs(pageBaseURL, tplData.MaxPage)...)
+
+	// Render HTML
+	templateName := meta.ArchiveTemplate
+	if templateName == "" {
+		templateName = "archive"
+	}
+
+	return childURLs, wk.renderHTML(w, tplData, meta.Theme, dirURLPath, templateName)
+}
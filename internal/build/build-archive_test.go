@@ -0,0 +1,53 @@
+package build
+
+import "testing"
+
+func TestArchiveOf(t *testing.T) {
+	wk := newTestWorker(t, map[string]string{
+		"blog/_index.md":      "+++\nTitle = \"Blog\"\nArchive = true\nPagination = 1\n+++\n",
+		"blog/a.md":           "+++\nTitle = \"A\"\n+++\n",
+		"blog/b.md":           "+++\nTitle = \"B\"\n+++\n",
+		"blog/2022.md":        "+++\nTitle = \"Year post\"\n+++\n",
+		"blog/2021/_index.md": "+++\nTitle = \"Year dir\"\n+++\n",
+		"docs/_index.md":      "+++\nTitle = \"Docs\"\n+++\n",
+	})
+
+	type archive struct {
+		dir   string
+		year  int
+		month int
+		page  int
+	}
+
+	tests := []struct {
+		urlPath  string
+		expected archive
+		ok       bool
+	}{
+		{"blog/2023", archive{"blog", 2023, 0, 1}, true},
+		{"blog/2023/2", archive{"blog", 2023, 0, 2}, true},
+		{"blog/2023/03", archive{"blog", 2023, 3, 1}, true},
+		{"blog/2023/03/2", archive{"blog", 2023, 3, 2}, true},
+		{"blog/2023/13", archive{"blog", 2023, 0, 13}, true},
+		{"blog/2023/03/page/2", archive{}, false},
+		{"blog/2022", archive{}, false},
+		{"blog/2022/03", archive{}, false},
+		{"blog/2021/2", archive{}, false},
+		{"blog/0003", archive{}, false},
+		{"blog/0003/02", archive{"blog", 3, 2, 1}, true},
+		{"docs/2023", archive{}, false},
+		{"blog/123", archive{}, false},
+	}
+
+	for _, test := range tests {
+		dir, year, month, page, ok := wk.archiveOf(test.urlPath)
+		if ok != test.ok {
+			t.Errorf("%s: expected ok %v, got %v", test.urlPath, test.ok, ok)
+			continue
+		}
+
+		if result := (archive{dir, year, month, page}); ok && result != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.urlPath, test.expected, result)
+		}
+	}
+}
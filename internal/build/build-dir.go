@@ -5,11 +5,8 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
-	"math"
 	"path"
 	fp "path/filepath"
-	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/RadhiFadlillah/boom/internal/fileutils"
@@ -36,6 +33,10 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 		return nil, fmt.Errorf("%s is not part of site content", urlPath)
 	}
 
+	if wk.isHeadless(indexMdPath) {
+		return nil, ErrHeadlessContent
+	}
+
 	// Parse metadata
 	meta, content, err := wk.parsePath(indexMdPath)
 	if err != nil {
@@ -48,12 +49,15 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 		Title:       meta.Title,
 		Description: meta.Description,
 		Author:      meta.Author,
+		Cover:       meta.Cover,
+		Params:      meta.Params,
 		PageSize:    meta.Pagination,
 	}
 
 	// Set content
-	if !meta.Draft || (meta.Draft && wk.buildDraft) {
-		tplData.Content = content
+	if wk.isPublished(meta) {
+		tplData.Content = content.HTML
+		tplData.TOC = content.TOC
 	}
 
 	// Create path trails
@@ -91,15 +95,16 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 
 	subDirs := []model.ContentPath{}
 	subFiles := []model.ContentPath{}
+	hiddenURLs := []string{}
 	for _, item := range items {
 		itemName := item.Name()
 		itemExt := fp.Ext(itemName)
 		itemPath := fp.Join(dirPath, itemName)
 		itemURLPath := path.Join(cleanURLPath, strings.TrimSuffix(itemName, itemExt))
 
-		itemMeta, _, err := wk.parsePath(itemPath)
-		if err != nil {
-			return nil, err
+		// Skip tag descriptions since it's not part of content
+		if item.IsDir() && itemName == tagDescDir {
+			continue
 		}
 
 		if item.IsDir() {
@@ -111,8 +116,12 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 			nChild := 0
 			for _, subItem := range subDirItems {
 				subItemName := subItem.Name()
+				subItemPath := fp.Join(itemPath, subItemName)
 				if subItem.IsDir() {
-					nChild++
+					subDirMeta, _, _ := wk.parseMarkdown(fp.Join(subItemPath, "_index.md"))
+					if subItemName != tagDescDir && !subDirMeta.Hidden && !subDirMeta.Headless {
+						nChild++
+					}
 					continue
 				}
 
@@ -120,69 +129,85 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 					continue
 				}
 
-				subItemPath := fp.Join(itemPath, subItemName)
 				subItemMeta, _, _ := wk.parsePath(subItemPath)
-				if !subItemMeta.Draft || (subItemMeta.Draft && wk.buildDraft) {
+				if wk.isListed(subItemMeta) {
 					nChild++
 				}
 			}
 
-			subDirs = append(subDirs, model.ContentPath{
-				IsDir:   true,
-				URLPath: path.Join("/", itemURLPath),
-				Title:   itemMeta.Title,
-				NChild:  nChild,
-			})
+			// Sub dir uses metadata from its index file
+			itemMeta, itemContent, err := wk.parsePath(fp.Join(itemPath, "_index.md"))
+			if err != nil {
+				return nil, err
+			}
+
+			// Hidden dir is still built, while headless dir is not
+			if itemMeta.Hidden && !itemMeta.Headless {
+				hiddenURLs = append(hiddenURLs, itemURLPath)
+			}
+
+			if itemMeta.Hidden || itemMeta.Headless {
+				continue
+			}
+
+			subDir := wk.newContentPath(itemURLPath, true, itemMeta, itemContent)
+			subDir.NChild = nChild
+			subDirs = append(subDirs, subDir)
 			continue
 		}
 
-		if !item.IsDir() && itemExt == ".md" && itemName != "_index.md" {
-			if itemMeta.Draft && !wk.buildDraft {
-				continue
+		if itemExt == ".md" && itemName != "_index.md" {
+			itemMeta, itemContent, err := wk.parsePath(itemPath)
+			if err != nil {
+				return nil, err
+			}
+
+			// Hidden file is still built, but not listed
+			if itemMeta.Hidden && !itemMeta.Headless && wk.isPublished(itemMeta) {
+				hiddenURLs = append(hiddenURLs, itemURLPath)
 			}
 
-			itemTime := itemMeta.UpdateTime
-			if itemTime.IsZero() {
-				itemTime = itemMeta.CreateTime
+			if !wk.isListed(itemMeta) {
+				continue
 			}
 
-			subFiles = append(subFiles, model.ContentPath{
-				URLPath:    path.Join("/", itemURLPath),
-				Title:      itemMeta.Title,
-				UpdateTime: itemTime,
-			})
+			subFiles = append(subFiles, wk.newContentPath(itemURLPath, false, itemMeta, itemContent))
 		}
 	}
 
 	// Sort items
-	sort.Slice(subDirs, func(a, b int) bool {
-		titleA := subDirs[a].Title
-		titleB := subDirs[b].Title
-		return strings.ToLower(titleA) < strings.ToLower(titleB)
-	})
-
-	sort.Slice(subFiles, func(a, b int) bool {
-		timeA := subFiles[a].UpdateTime
-		timeB := subFiles[b].UpdateTime
-		if !timeA.Equal(timeB) {
-			return timeA.After(timeB)
-		}
+	sortKey, sortOrder := dirSortOrder(meta, true)
+	subDirs, err = sortContentPaths(subDirs, sortKey, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexMdPath, err)
+	}
 
-		titleA := subFiles[a].Title
-		titleB := subFiles[b].Title
-		return strings.ToLower(titleA) < strings.ToLower(titleB)
-	})
+	sortKey, sortOrder = dirSortOrder(meta, false)
+	subFiles, err = sortContentPaths(subFiles, sortKey, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexMdPath, err)
+	}
 
 	// Merge sub dirs and sub files
 	dirItems := append(subDirs, subFiles...)
 
 	// Fetch all tags within active directory
-	mapDirTags := make(map[string]int)
+	dirTagCounter := newTagCounter()
 	fnWalk := func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Skip tag descriptions since it's not part of content
+		if d.IsDir() && d.Name() == tagDescDir {
+			return fp.SkipDir
+		}
+
+		// Skip hidden and headless dir since its content is not listed
+		if d.IsDir() && path != dirPath && wk.isUnlistedDir(path, false) {
+			return fp.SkipDir
+		}
+
 		// We look for markdown file
 		if d.IsDir() || fp.Ext(path) != ".md" || fp.Base(path) == "_index.md" {
 			return nil
@@ -194,10 +219,12 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 			return err
 		}
 
-		// Save tags
-		for _, tag := range fileMeta.Tags {
-			mapDirTags[tag]++
+		if !wk.isListed(fileMeta) {
+			return nil
 		}
+
+		// Save tags
+		dirTagCounter.AddFile(wk.normalizeTags(fileMeta.Tags))
 		return nil
 	}
 
@@ -206,56 +233,29 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 		return nil, err
 	}
 
-	// Sort tags
-	dirTags := []model.TagPath{}
-	for tag, count := range mapDirTags {
-		dirTags = append(dirTags, model.TagPath{
-			URLPath: path.Join("/", cleanURLPath, "tag-"+tag),
-			Name:    tag,
-			Count:   count,
-		})
-	}
+	wk.recordTagCollisions(dirTagCounter)
 
-	sort.Slice(dirTags, func(a, b int) bool {
-		countA := dirTags[a].Count
-		countB := dirTags[b].Count
-		if countA != countB {
-			return countA > countB
-		}
-
-		nameA := dirTags[a].Name
-		nameB := dirTags[b].Name
-		return strings.ToLower(nameA) < strings.ToLower(nameB)
+	// Sort tags
+	tplData.ChildTags, tplData.TagTree = dirTagCounter.TagPaths(func(slug string) string {
+		return path.Join("/", cleanURLPath, "tag-"+slug)
 	})
 
-	tplData.ChildTags = dirTags
-
-	// Calculate pagination stuffs
-	if tplData.PageSize <= 0 {
-		tplData.CurrentPage = 1
-		tplData.MaxPage = 1
-		tplData.ChildItems = dirItems
-	} else {
-		tplData.MaxPage = int(math.Ceil(float64(len(dirItems)) / float64(tplData.PageSize)))
-
-		switch {
-		case pageNumber <= 0:
-			tplData.CurrentPage = 1
-		case pageNumber > tplData.MaxPage:
-			tplData.CurrentPage = tplData.MaxPage
-		default:
-			tplData.CurrentPage = pageNumber
-		}
-
-		startIdx := (tplData.CurrentPage - 1) * tplData.PageSize
-		endIdx := (tplData.CurrentPage * tplData.PageSize)
-		if nFiles := len(dirItems); endIdx > nFiles {
-			endIdx = nFiles
+	// Group files by its period for archive
+	if meta.Archive {
+		allFiles, err := wk.listPages(cleanURLPath, true, false)
+		if err != nil {
+			return nil, err
 		}
 
-		tplData.ChildItems = dirItems[startIdx:endIdx]
+		tplData.Archive = archiveGroups(cleanURLPath, allFiles)
 	}
 
+	// Calculate pagination stuffs
+	page := paginate(len(dirItems), tplData.PageSize, pageNumber)
+	tplData.CurrentPage = page.CurrentPage
+	tplData.MaxPage = page.MaxPage
+	tplData.ChildItems = dirItems[page.Start:page.End]
+
 	// Create child URLs
 	childURLs := []string{}
 
@@ -263,13 +263,25 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 		childURLs = append(childURLs, strings.TrimPrefix(child.URLPath, "/"))
 	}
 
+	childURLs = append(childURLs, hiddenURLs...)
+
 	for _, tag := range tplData.ChildTags {
 		childURLs = append(childURLs, strings.TrimPrefix(tag.URLPath, "/"))
 	}
 
-	if tplData.MaxPage > 1 {
-		for i := 1; i <= tplData.MaxPage; i++ {
-			childURLs = append(childURLs, path.Join(cleanURLPath, strconv.Itoa(i)))
+	childURLs = append(childURLs, pageURLs(cleanURLPath, tplData.MaxPage)...)
+
+	for _, yearGroup := range tplData.Archive {
+		childURLs = append(childURLs, strings.TrimPrefix(yearGroup.URLPath, "/"))
+	}
+
+	if dirPath == wk.ContentDir {
+		if meta.SiteTags {
+			childURLs = append(childURLs, siteTagsURL)
+		}
+
+		for _, tx := range wk.taxonomies {
+			childURLs = append(childURLs, tx.URLPrefix)
 		}
 	}
 
@@ -280,5 +292,5 @@ func (wk *Worker) buildDir(urlPath string, w io.Writer) ([]string, error) {
 		templateName = "directory"
 	}
 
-	return childURLs, wk.renderHTML(w, tplData, theme, templateName)
+	return childURLs, wk.renderHTML(w, tplData, theme, cleanURLPath, templateName)
 }
@@ -4,10 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path"
 	fp "path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/RadhiFadlillah/boom/internal/fileutils"
@@ -17,6 +15,14 @@ import (
 // ErrDraftFile is error to notify that file is a draft.
 var ErrDraftFile = errors.New("file is draft")
 
+// ErrUnpublishedFile is error to notify that file is not published yet, or
+// its expiry time has passed.
+var ErrUnpublishedFile = errors.New("file is not published")
+
+// ErrHeadlessContent is error to notify that content is headless, so it's
+// not rendered on its own.
+var ErrHeadlessContent = errors.New("content is headless")
+
 // buildFile builds file for specified URL path.
 func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 	// Create file path from URL
@@ -31,11 +37,19 @@ func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 		return err
 	}
 
-	// If it's draft, stop early
+	// If it's draft, unpublished or headless, stop early
 	if meta.Draft && !wk.buildDraft {
 		return ErrDraftFile
 	}
 
+	if !wk.isPublished(meta) {
+		return ErrUnpublishedFile
+	}
+
+	if wk.isHeadless(filePath) {
+		return ErrHeadlessContent
+	}
+
 	// Create template data
 	tplData := model.FileData{
 		URLPath:     path.Join("/", urlPath),
@@ -44,7 +58,13 @@ func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 		Author:      meta.Author,
 		CreateTime:  meta.CreateTime,
 		UpdateTime:  meta.UpdateTime,
-		Content:     content,
+		Cover:       meta.Cover,
+		Params:      meta.Params,
+		Content:     content.HTML,
+		TOC:         content.TOC,
+		Summary:     content.Summary,
+		WordCount:   content.WordCount,
+		ReadingTime: content.ReadingTime,
 	}
 
 	// Create path trails
@@ -72,64 +92,27 @@ func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 	)
 
 	// Fetch file tags
-	sort.Strings(meta.Tags)
 	dirURLPath := path.Dir(urlPath)
-	for _, tag := range meta.Tags {
-		tplData.Tags = append(tplData.Tags, model.TagPath{
-			URLPath: path.Join("/", dirURLPath, "tag-"+tag),
-			Name:    tag,
-		})
+	tplData.Tags = wk.tagPaths(dirURLPath, meta.Tags)
+
+	// Fetch terms of custom taxonomies
+	for _, tx := range wk.taxonomies {
+		if tplData.Terms == nil {
+			tplData.Terms = make(map[string][]model.TagPath)
+		}
+		tplData.Terms[tx.Name] = termPaths(meta, tx)
 	}
 
-	// Get sibling files
-	dirPath := fp.Dir(filePath)
-	dirItems, err := ioutil.ReadDir(dirPath)
+	// Get sibling files, in the same order as the directory listing
+	dirFiles, err := wk.listPages(dirURLPath, false, false)
 	if err != nil {
 		return err
 	}
 
 	fileIdx := -1
-	dirFiles := []model.ContentPath{}
-	for _, item := range dirItems {
-		// Make sure it's ordinary markdown file
-		itemName := item.Name()
-		itemExt := fp.Ext(itemName)
-		if item.IsDir() || itemExt != ".md" || itemName == "_index.md" {
-			continue
-		}
-
-		// Make sure it's not draft
-		itemPath := fp.Join(dirPath, itemName)
-		itemMeta, _, err := wk.parsePath(itemPath)
-		if err != nil {
-			return err
-		}
-
-		if itemMeta.Draft && !wk.buildDraft {
-			continue
-		}
-
-		// Add item to file list
-		itemTime := itemMeta.UpdateTime
-		if itemTime.IsZero() {
-			itemTime = itemMeta.CreateTime
-		}
-
-		itemName = strings.TrimSuffix(itemName, itemExt)
-		itemURLPath := path.Join("/", dirURLPath, itemName)
-		dirFiles = append(dirFiles, model.ContentPath{
-			URLPath:    itemURLPath,
-			Title:      itemMeta.Title,
-			UpdateTime: itemTime,
-		})
-
-		// If this item is the current file, save its index
-		if itemURLPath == tplData.URLPath {
-			fileIdx = len(dirFiles) - 1
-			continue
-		}
-
-		if fileIdx >= 0 && len(dirFiles) > fileIdx+1 {
+	for i, file := range dirFiles {
+		if file.URLPath == tplData.URLPath {
+			fileIdx = i
 			break
 		}
 	}
@@ -144,6 +127,32 @@ func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 		}
 	}
 
+	// Get other files in the same series
+	if series := strings.TrimSpace(meta.Series); series != "" {
+		seriesItems, err := wk.seriesItems(series)
+		if err != nil {
+			return err
+		}
+
+		tplData.Series = series
+		tplData.SeriesItems = seriesItems
+		for i, item := range seriesItems {
+			if item.URLPath != tplData.URLPath {
+				continue
+			}
+
+			tplData.SeriesPosition = i + 1
+			if i > 0 {
+				tplData.SeriesPrev = seriesItems[i-1]
+			}
+
+			if i < len(seriesItems)-1 {
+				tplData.SeriesNext = seriesItems[i+1]
+			}
+			break
+		}
+	}
+
 	// Render HTML
 	theme := meta.Theme
 	templateName := meta.FileTemplate
@@ -151,5 +160,5 @@ func (wk *Worker) buildFile(urlPath string, w io.Writer) error {
 		templateName = "file"
 	}
 
-	return wk.renderHTML(w, tplData, theme, templateName)
+	return wk.renderHTML(w, tplData, theme, path.Dir(urlPath), templateName)
 }
@@ -0,0 +1,187 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"path"
+	fp "path/filepath"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// siteTagsURL is the URL path for list of tags in the entire site.
+const siteTagsURL = "tags"
+
+// isSiteTagsURL checks whether the URL path points to site wide tag pages,
+// which only exist if it's enabled in root metadata.
+func (wk *Worker) isSiteTagsURL(urlPath string) bool {
+	if urlPath != siteTagsURL && !strings.HasPrefix(urlPath, siteTagsURL+"/") {
+		return false
+	}
+
+	rootMeta, _, err := wk.parsePath(fp.Join(wk.ContentDir, "_index.md"))
+	return err == nil && rootMeta.SiteTags
+}
+
+// checkSiteTags makes sure there are no content in URL of site wide tags,
+// since it would be hidden by the tag pages.
+func (wk *Worker) checkSiteTags() error {
+	tagsPath := fp.Join(wk.ContentDir, siteTagsURL)
+	if fileutils.IsDir(tagsPath) || fileutils.IsFile(tagsPath+".md") {
+		return fmt.Errorf("content/%s is reserved for site wide tags, rename it or disable SiteTags", siteTagsURL)
+	}
+
+	return nil
+}
+
+// buildSiteTags builds list of tags in the entire site, or list of files
+// for a tag if the URL path contains tag name.
+func (wk *Worker) buildSiteTags(urlPath string, w io.Writer) ([]string, error) {
+	// Parse root metadata
+	meta, _, err := wk.parsePath(fp.Join(wk.ContentDir, "_index.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = wk.checkSiteTags(); err != nil {
+		return nil, err
+	}
+
+	// Fetch all files in the site, grouped by its tags
+	allFiles, err := wk.listPages("/", true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	siteTagCounter := newTagCounter()
+	allFileTags := make([][]string, len(allFiles))
+	for i, file := range allFiles {
+		fileTags := []string{}
+		for _, tag := range file.Tags {
+			fileTags = append(fileTags, tag.Name)
+		}
+
+		names, slugs := wk.normalizeTags(fileTags)
+		siteTagCounter.AddFile(names, slugs)
+		allFileTags[i] = slugs
+	}
+
+	wk.recordTagCollisions(siteTagCounter)
+
+	// If tag name is not specified, render list of tags
+	if urlPath == siteTagsURL {
+		return wk.buildSiteTagList(meta, siteTagCounter, w)
+	}
+
+	// Fetch page number and tag name from URL. Since tag might be
+	// hierarchical, the last number is a page number unless there is
+	// a tag with that name, e.g. year/2023
+	tagName := strings.TrimPrefix(urlPath, siteTagsURL+"/")
+	pageNumber := 1
+
+	// Tag in URL might be written differently, so use its slug
+	tagSlug := wk.tagSlug(tagName)
+	_, tagExist := siteTagCounter.Slugs()[tagSlug]
+	if isNum, number := isNumber(path.Base(tagSlug)); isNum && !tagExist && path.Dir(tagSlug) != "." {
+		pageNumber = number
+		tagName = path.Dir(tagName)
+		tagSlug = path.Dir(tagSlug)
+		_, tagExist = siteTagCounter.Slugs()[tagSlug]
+	}
+
+	if !tagExist {
+		return nil, fmt.Errorf("%s: %w", urlPath, ErrNotFound)
+	}
+
+	tagURLPath := path.Join("/", siteTagsURL, tagSlug)
+	if name := siteTagCounter.Name(tagSlug); name != "" {
+		tagName = name
+	}
+
+	// Create template data
+	tplData := model.TagFilesData{
+		URLPath:   path.Join("/", urlPath),
+		ActiveTag: tagName,
+		Title:     meta.Title,
+		PageSize:  meta.Pagination,
+		PathTrails: []model.ContentPath{
+			{URLPath: "/", Title: meta.Title, IsDir: true},
+			{URLPath: path.Join("/", siteTagsURL), Title: "Tags"},
+			{URLPath: tagURLPath, Title: "#" + tagName},
+		},
+	}
+
+	// Fetch description of the tag
+	descMeta, descContent, descExist, err := wk.tagDescription(wk.ContentDir, tagSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if descExist {
+		tplData.Description = descMeta.Description
+		tplData.Content = descContent.HTML
+	}
+
+	// Fetch files that use the tag or its descendants
+	files := []model.ContentPath{}
+	for i, file := range allFiles {
+		for _, slug := range allFileTags[i] {
+			if tagIncludes(tagSlug, slug) {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+
+	// Calculate pagination stuffs
+	page := paginate(len(files), tplData.PageSize, pageNumber)
+	tplData.CurrentPage = page.CurrentPage
+	tplData.MaxPage = page.MaxPage
+	tplData.Files = files[page.Start:page.End]
+
+	// Create child URLs
+	childURLs := pageURLs(path.Join(siteTagsURL, tagSlug), tplData.MaxPage)
+
+	// Render HTML
+	templateName := meta.TagFilesTemplate
+	if templateName == "" {
+		templateName = "tagfiles"
+	}
+
+	return childURLs, wk.renderHTML(w, tplData, meta.Theme, siteTagsURL, templateName)
+}
+
+// buildSiteTagList builds list of all tags in the site, along with count of
+// files that use it.
+func (wk *Worker) buildSiteTagList(meta model.Metadata, siteTagCounter tagCounter, w io.Writer) ([]string, error) {
+	// Create template data
+	tplData := model.TagsData{
+		URLPath: path.Join("/", siteTagsURL),
+		Title:   "Tags",
+		PathTrails: []model.ContentPath{
+			{URLPath: "/", Title: meta.Title, IsDir: true},
+			{URLPath: path.Join("/", siteTagsURL), Title: "Tags"},
+		},
+	}
+
+	// Create list and tree of tags
+	tplData.Tags, tplData.TagTree = siteTagCounter.TagPaths(func(slug string) string {
+		return path.Join("/", siteTagsURL, slug)
+	})
+
+	// Create child URLs
+	childURLs := []string{}
+	for _, tag := range tplData.Tags {
+		childURLs = append(childURLs, strings.TrimPrefix(tag.URLPath, "/"))
+	}
+
+	// Render HTML
+	templateName := meta.TagsTemplate
+	if templateName == "" {
+		templateName = "tags"
+	}
+
+	return childURLs, wk.renderHTML(w, tplData, meta.Theme, siteTagsURL, templateName)
+}
@@ -4,11 +4,8 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"math"
 	"path"
 	fp "path/filepath"
-	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/RadhiFadlillah/boom/internal/fileutils"
@@ -17,21 +14,16 @@ import (
 
 // buildTagFiles builds tag files list for specified URL path.
 func (wk *Worker) buildTagFiles(urlPath string, w io.Writer) ([]string, error) {
-	// Fetch page number and tag name from URL
-	tagName := ""
-	pageNumber := 1
-	cleanURLPath := urlPath
-
-	urlPathBase := path.Base(cleanURLPath)
-	if isNum, number := isNumber(urlPathBase); isNum {
-		pageNumber = number
-		cleanURLPath = path.Dir(cleanURLPath)
-	}
+	// Split URL into directory and tag, which might be hierarchical
+	// and followed by page number, e.g. blog/tag-lang/go/2
+	cleanURLPath, tagName := splitTagURL(urlPath)
 
-	urlPathBase = path.Base(cleanURLPath)
-	if strings.HasPrefix(urlPathBase, "tag-") {
-		tagName = strings.TrimPrefix(urlPathBase, "tag-")
-		cleanURLPath = path.Dir(cleanURLPath)
+	// Tag in URL might be written differently, so use its slug
+	tagSlug := wk.tagSlug(tagName)
+	pageNumber := 1
+	pagedTagSlug, pagedNumber := "", 0
+	if isNum, number := isNumber(path.Base(tagSlug)); isNum && path.Dir(tagSlug) != "." {
+		pagedTagSlug, pagedNumber = path.Dir(tagSlug), number
 	}
 
 	// Now since the URL path clean from tag name and page number,
@@ -83,45 +75,48 @@ func (wk *Worker) buildTagFiles(urlPath string, w io.Writer) ([]string, error) {
 			Title:   meta.Title,
 		},
 		model.ContentPath{
-			URLPath: path.Join("/", cleanURLPath, "tag-"+tagName),
+			URLPath: path.Join("/", cleanURLPath, "tag-"+tagSlug),
 			Title:   "#" + tagName,
 		},
 	)
 
-	// Fetch files that uses our active tag
-	files := []model.ContentPath{}
+	// Fetch all files along with its tags
+	allFiles := []model.ContentPath{}
+	allFileTags := [][]string{}
+	dirTagCounter := newTagCounter()
 	fnWalk := func(fPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Skip tag descriptions since it's not part of content
+		if d.IsDir() && d.Name() == tagDescDir {
+			return fp.SkipDir
+		}
+
+		// Skip hidden and headless dir since its content is not listed
+		if d.IsDir() && fPath != dirPath && wk.isUnlistedDir(fPath, false) {
+			return fp.SkipDir
+		}
+
 		// We look for markdown file
 		if d.IsDir() || fp.Ext(fPath) != ".md" || fp.Base(fPath) == "_index.md" {
 			return nil
 		}
 
 		// Parse file
-		fileMeta, _, err := wk.parsePath(fPath)
+		fileMeta, fileContent, err := wk.parsePath(fPath)
 		if err != nil {
 			return err
 		}
 
-		if fileMeta.Draft && !wk.buildDraft {
+		if !wk.isListed(fileMeta) {
 			return nil
 		}
 
-		// Make sure this file uses active tag
-		useTag := false
-		for _, tag := range fileMeta.Tags {
-			if tag == tplData.ActiveTag {
-				useTag = true
-				break
-			}
-		}
-
-		if !useTag {
-			return nil
-		}
+		// Save the tags
+		names, slugs := wk.normalizeTags(fileMeta.Tags)
+		dirTagCounter.AddFile(names, slugs)
 
 		// Generate URL path
 		fPath = strings.TrimSuffix(fPath, ".md")
@@ -131,16 +126,8 @@ func (wk *Worker) buildTagFiles(urlPath string, w io.Writer) ([]string, error) {
 		}
 
 		// Add it to list of file
-		fileTime := fileMeta.UpdateTime
-		if fileTime.IsZero() {
-			fileTime = fileMeta.CreateTime
-		}
-
-		files = append(files, model.ContentPath{
-			Title:      fileMeta.Title,
-			URLPath:    path.Join("/", fileURLPath),
-			UpdateTime: fileTime,
-		})
+		allFiles = append(allFiles, wk.newContentPath(fileURLPath, false, fileMeta, fileContent))
+		allFileTags = append(allFileTags, slugs)
 		return nil
 	}
 
@@ -149,54 +136,66 @@ func (wk *Worker) buildTagFiles(urlPath string, w io.Writer) ([]string, error) {
 		return nil, err
 	}
 
-	// Sort files
-	sort.Slice(files, func(a, b int) bool {
-		timeA := files[a].UpdateTime
-		timeB := files[b].UpdateTime
-		if !timeA.Equal(timeB) {
-			return timeA.After(timeB)
-		}
+	// If the last segment of tag is a number, it's a page number unless
+	// there is a tag with that name, e.g. year/2023
+	if _, exist := dirTagCounter.Slugs()[tagSlug]; !exist && pagedTagSlug != "" {
+		tagSlug, pageNumber = pagedTagSlug, pagedNumber
+		tagName = path.Dir(tagName)
+	}
 
-		titleA := files[a].Title
-		titleB := files[b].Title
-		return strings.ToLower(titleA) < strings.ToLower(titleB)
-	})
+	if _, exist := dirTagCounter.Slugs()[tagSlug]; !exist {
+		return nil, fmt.Errorf("%s: %w", urlPath, ErrNotFound)
+	}
 
-	// Calculate pagination stuffs
-	if tplData.PageSize <= 0 {
-		tplData.CurrentPage = 1
-		tplData.MaxPage = 1
-		tplData.Files = files
-	} else {
-		tplData.MaxPage = int(math.Ceil(float64(len(files)) / float64(tplData.PageSize)))
-
-		switch {
-		case pageNumber <= 0:
-			tplData.CurrentPage = 1
-		case pageNumber > tplData.MaxPage:
-			tplData.CurrentPage = tplData.MaxPage
-		default:
-			tplData.CurrentPage = pageNumber
+	// Fetch files that uses our active tag or its descendants
+	files := []model.ContentPath{}
+	for i, file := range allFiles {
+		for _, slug := range allFileTags[i] {
+			if tagIncludes(tagSlug, slug) {
+				files = append(files, file)
+				break
+			}
 		}
+	}
 
-		startIdx := (tplData.CurrentPage - 1) * tplData.PageSize
-		endIdx := (tplData.CurrentPage * tplData.PageSize)
-		if nFiles := len(files); endIdx > nFiles {
-			endIdx = nFiles
-		}
+	// Use the display name of active tag
+	if name := dirTagCounter.Name(tagSlug); name != "" {
+		tagName = name
+	}
 
-		tplData.Files = files[startIdx:endIdx]
+	tplData.ActiveTag = tagName
+	tplData.PathTrails[len(tplData.PathTrails)-1] = model.ContentPath{
+		URLPath: path.Join("/", cleanURLPath, "tag-"+tagSlug),
+		Title:   "#" + tagName,
 	}
 
-	// Create child URLs
-	childURLs := []string{}
-	if tplData.MaxPage > 1 {
-		for i := 1; i <= tplData.MaxPage; i++ {
-			pageURL := path.Join(cleanURLPath, "tag-"+tagName, strconv.Itoa(i))
-			childURLs = append(childURLs, pageURL)
-		}
+	// Fetch description of the tag
+	descMeta, descContent, descExist, err := wk.tagDescription(dirPath, tagSlug)
+	if err != nil {
+		return nil, err
 	}
 
+	if descExist {
+		tplData.Description = descMeta.Description
+		tplData.Content = descContent.HTML
+	}
+
+	// Sort files
+	sortKey, sortOrder := dirSortOrder(meta, false)
+	files, err = sortContentPaths(files, sortKey, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexMdPath, err)
+	}
+
+	// Calculate pagination stuffs
+	page := paginate(len(files), tplData.PageSize, pageNumber)
+	tplData.CurrentPage = page.CurrentPage
+	tplData.MaxPage = page.MaxPage
+	tplData.Files = files[page.Start:page.End]
+
+	// Create child URLs
+	childURLs := pageURLs(path.Join(cleanURLPath, "tag-"+tagSlug), tplData.MaxPage)
+
 	// Render HTML
 	theme := meta.Theme
 	templateName := meta.TagFilesTemplate
@@ -204,5 +203,5 @@ func (wk *Worker) buildTagFiles(urlPath string, w io.Writer) ([]string, error) {
 		templateName = "tagfiles"
 	}
 
-	return childURLs, wk.renderHTML(w, tplData, theme, templateName)
+	return childURLs, wk.renderHTML(w, tplData, theme, cleanURLPath, templateName)
 }
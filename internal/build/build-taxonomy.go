@@ -0,0 +1,305 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"path"
+	fp "path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// siteTaxonomies returns custom taxonomies that declared in root metadata,
+// with its empty fields filled by the default value.
+func siteTaxonomies(rootMeta model.Metadata) []model.TaxonomyConfig {
+	taxonomies := []model.TaxonomyConfig{}
+	for _, tx := range rootMeta.Taxonomies {
+		if tx.Name == "" {
+			continue
+		}
+
+		if tx.Key == "" {
+			tx.Key = tx.Name
+		}
+
+		tx.URLPrefix = strings.Trim(tx.URLPrefix, "/")
+		if tx.URLPrefix == "" {
+			tx.URLPrefix = slugify(tx.Name)
+		}
+
+		if tx.Title == "" {
+			tx.Title = titleCase(tx.Name)
+		}
+
+		if tx.TermsTemplate == "" {
+			tx.TermsTemplate = "terms"
+		}
+
+		if tx.TermFilesTemplate == "" {
+			tx.TermFilesTemplate = "termfiles"
+		}
+
+		if tx.Pagination == 0 {
+			tx.Pagination = rootMeta.Pagination
+		}
+
+		if tx.SortBy == "" {
+			tx.SortBy = "date"
+		}
+
+		if tx.SortOrder == "" {
+			tx.SortOrder = defaultSortOrder(tx.SortBy)
+		}
+
+		taxonomies = append(taxonomies, tx)
+	}
+
+	return taxonomies
+}
+
+// isTaxonomyURL checks whether the URL path points to pages of a custom
+// taxonomy.
+func (wk *Worker) isTaxonomyURL(urlPath string) bool {
+	_, exist := wk.taxonomyOf(urlPath)
+	return exist
+}
+
+// taxonomyOf returns the custom taxonomy whose pages contain the URL path.
+func (wk *Worker) taxonomyOf(urlPath string) (model.TaxonomyConfig, bool) {
+	for _, tx := range wk.taxonomies {
+		if urlPath == tx.URLPrefix || strings.HasPrefix(urlPath, tx.URLPrefix+"/") {
+			return tx, true
+		}
+	}
+
+	return model.TaxonomyConfig{}, false
+}
+
+// taxonomyTerms returns terms of the taxonomy that used in metadata. The
+// front matter key is case insensitive, and its value could be a string or
+// list of strings.
+func taxonomyTerms(meta model.Metadata, tx model.TaxonomyConfig) []string {
+	var value interface{}
+	for key, v := range meta.FrontMatter {
+		if strings.EqualFold(key, tx.Key) {
+			value = v
+			break
+		}
+	}
+
+	terms := []string{}
+	switch v := value.(type) {
+	case string:
+		terms = append(terms, v)
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				terms = append(terms, str)
+			}
+		}
+	}
+
+	result := []string{}
+	for _, term := range terms {
+		if term = strings.TrimSpace(term); term != "" {
+			result = append(result, term)
+		}
+	}
+
+	return result
+}
+
+// termPaths returns TagPath for each term of the taxonomy that used in
+// metadata, sorted by its name.
+func termPaths(meta model.Metadata, tx model.TaxonomyConfig) []model.TagPath {
+	terms := taxonomyTerms(meta, tx)
+	sort.Strings(terms)
+
+	paths := []model.TagPath{}
+	for _, term := range terms {
+		paths = append(paths, model.TagPath{
+			URLPath: path.Join("/", tx.URLPrefix, slugify(term)),
+			Name:    term,
+		})
+	}
+
+	return paths
+}
+
+// termIndex is files of a custom taxonomy, grouped by slug of its terms.
+type termIndex struct {
+	counter tagCounter
+	files   map[string][]model.ContentPath
+}
+
+// termIndex groups every file in the site by slug of its terms in the
+// taxonomy. Since it's needed by every page of the taxonomy, the terms are
+// read from raw metadata and the result is cached when cache is enabled.
+func (wk *Worker) termIndex(tx model.TaxonomyConfig) (termIndex, error) {
+	if wk.cacheEnabled {
+		if index, cached := wk.termCache[tx.Name]; cached {
+			return index, nil
+		}
+	}
+
+	files, err := wk.listPages("/", true, false)
+	if err != nil {
+		return termIndex{}, err
+	}
+
+	index := termIndex{
+		counter: newTagCounter(),
+		files:   make(map[string][]model.ContentPath),
+	}
+
+	for _, file := range files {
+		fileMeta, err := wk.rawMeta(fp.Join(wk.ContentDir, file.URLPath+".md"))
+		if err != nil {
+			return termIndex{}, err
+		}
+
+		// File is only counted once for each slug, even if it uses
+		// several terms with the same slug
+		added := make(map[string]struct{})
+		for _, term := range taxonomyTerms(fileMeta, tx) {
+			slug := slugify(term)
+			index.counter.Add(term, slug)
+			if _, exist := added[slug]; !exist {
+				added[slug] = struct{}{}
+				index.files[slug] = append(index.files[slug], file)
+			}
+		}
+	}
+
+	if wk.cacheEnabled {
+		wk.termCache[tx.Name] = index
+	}
+
+	return index, nil
+}
+
+// buildTaxonomy builds list of terms in a custom taxonomy, or list of files
+// for a term if the URL path contains the term.
+func (wk *Worker) buildTaxonomy(urlPath string, w io.Writer) ([]string, error) {
+	// Find the taxonomy
+	tx, exist := wk.taxonomyOf(urlPath)
+	if !exist {
+		return nil, fmt.Errorf("%s is not part of site taxonomies", urlPath)
+	}
+
+	// Parse root metadata
+	meta, _, err := wk.parsePath(fp.Join(wk.ContentDir, "_index.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch all files in the site, grouped by slug of its terms
+	index, err := wk.termIndex(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	termFiles := index.files
+	termNames := make(map[string]string)
+	for slug := range termFiles {
+		termNames[slug] = index.counter.Name(slug)
+	}
+
+	// If term is not specified, render list of terms
+	if urlPath == tx.URLPrefix {
+		return wk.buildTermList(meta, tx, termNames, termFiles, w)
+	}
+
+	// Fetch page number and term from URL
+	termSlug := strings.TrimPrefix(urlPath, tx.URLPrefix+"/")
+	pageNumber := 1
+
+	if isNum, number := isNumber(path.Base(termSlug)); isNum && path.Dir(termSlug) != "." {
+		pageNumber = number
+		termSlug = path.Dir(termSlug)
+	}
+
+	termName, termExist := termNames[termSlug]
+	if !termExist {
+		return nil, fmt.Errorf("%s: %w", urlPath, ErrNotFound)
+	}
+
+	// Create template data
+	tplData := model.TermFilesData{
+		URLPath:    path.Join("/", urlPath),
+		Taxonomy:   tx.Name,
+		ActiveTerm: termName,
+		Title:      tx.Title,
+		PageSize:   tx.Pagination,
+		PathTrails: []model.ContentPath{
+			{URLPath: "/", Title: meta.Title, IsDir: true},
+			{URLPath: path.Join("/", tx.URLPrefix), Title: tx.Title},
+			{URLPath: path.Join("/", tx.URLPrefix, termSlug), Title: termName},
+		},
+	}
+
+	// Sort files
+	files, err := sortContentPaths(termFiles[termSlug], tx.SortBy, tx.SortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("taxonomy %s: %w", tx.Name, err)
+	}
+
+	// Calculate pagination stuffs
+	page := paginate(len(files), tplData.PageSize, pageNumber)
+	tplData.CurrentPage = page.CurrentPage
+	tplData.MaxPage = page.MaxPage
+	tplData.Files = files[page.Start:page.End]
+
+	// Create child URLs
+	childURLs := pageURLs(path.Join(tx.URLPrefix, termSlug), tplData.MaxPage)
+
+	// Render HTML
+	return childURLs, wk.renderHTML(w, tplData, meta.Theme, tx.URLPrefix, tx.TermFilesTemplate)
+}
+
+// buildTermList builds list of terms in a custom taxonomy, along with count
+// of files that use it.
+func (wk *Worker) buildTermList(meta model.Metadata, tx model.TaxonomyConfig, termNames map[string]string, termFiles map[string][]model.ContentPath, w io.Writer) ([]string, error) {
+	// Create template data
+	tplData := model.TermsData{
+		URLPath:  path.Join("/", tx.URLPrefix),
+		Taxonomy: tx.Name,
+		Title:    tx.Title,
+		PathTrails: []model.ContentPath{
+			{URLPath: "/", Title: meta.Title, IsDir: true},
+			{URLPath: path.Join("/", tx.URLPrefix), Title: tx.Title},
+		},
+	}
+
+	// Sort terms
+	for slug, files := range termFiles {
+		tplData.Terms = append(tplData.Terms, model.TagPath{
+			URLPath: path.Join("/", tx.URLPrefix, slug),
+			Name:    termNames[slug],
+			Count:   len(files),
+		})
+	}
+
+	sort.Slice(tplData.Terms, func(a, b int) bool {
+		countA := tplData.Terms[a].Count
+		countB := tplData.Terms[b].Count
+		if countA != countB {
+			return countA > countB
+		}
+
+		nameA := tplData.Terms[a].Name
+		nameB := tplData.Terms[b].Name
+		return strings.ToLower(nameA) < strings.ToLower(nameB)
+	})
+
+	// Create child URLs
+	childURLs := []string{}
+	for _, term := range tplData.Terms {
+		childURLs = append(childURLs, strings.TrimPrefix(term.URLPath, "/"))
+	}
+
+	// Render HTML
+	return childURLs, wk.renderHTML(w, tplData, meta.Theme, tx.URLPrefix, tx.TermsTemplate)
+}
@@ -0,0 +1,631 @@
+package build
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	fp "path/filepath"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// ThemeProblem is a problem that found while checking a theme.
+type ThemeProblem struct {
+	Template string
+	Data     string
+	Err      error
+}
+
+func (p ThemeProblem) Error() string {
+	if p.Data == "" {
+		return fmt.Sprintf("%s: %v", p.Template, p.Err)
+	}
+
+	return fmt.Sprintf("%s with %s: %v", p.Template, p.Data, p.Err)
+}
+
+// syntheticData is a fake data that used to execute page template.
+type syntheticData struct {
+	Name string
+	Data interface{}
+}
+
+// CheckTheme checks every template in specified theme. Each template will be
+// parsed, then each page template will be executed using synthetic data. Page
+// template is a template that used for rendering directory, file, tag files,
+// site tags, taxonomy terms or archive, either the default one or the one that
+// specified in content metadata.
+func (wk *Worker) CheckTheme(themeName string) ([]ThemeProblem, error) {
+	// Load theme along with its ancestors
+	theme, err := LoadTheme(wk.RootDir, themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get list of page templates
+	pageTemplates, err := wk.pageTemplateNames()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse each template file separately, so parse error is only reported once
+	var problems []ThemeProblem
+	var templatePaths []string
+
+	err = fs.WalkDir(theme, ".", func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || path.Ext(fPath) != ".html" {
+			return nil
+		}
+
+		content, err := fs.ReadFile(theme, fPath)
+		if err != nil {
+			return err
+		}
+
+		_, err = template.New(fPath).Funcs(wk.funcMap()).Parse(string(content))
+		if err != nil {
+			problems = append(problems, ThemeProblem{Template: fPath, Err: err})
+			return nil
+		}
+
+		templatePaths = append(templatePaths, fPath)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Since every page template also loads the other templates, if there are
+	// templates that can't be parsed, page templates can't be created as well
+	if len(problems) > 0 {
+		return problems, nil
+	}
+
+	// Execute each page template, including the section specific ones
+	undefinedRefs := make(map[string]struct{})
+	for _, templatePath := range templatePaths {
+		if strings.HasPrefix(templatePath, "partials/") ||
+			strings.HasPrefix(templatePath, "shortcodes/") {
+			continue
+		}
+
+		templateName := strings.TrimSuffix(path.Base(templatePath), ".html")
+		dataList, isPage := pageTemplates[templateName]
+		if !isPage {
+			continue
+		}
+
+		section := path.Dir(templatePath)
+		tpl, err := wk.createTemplate(theme.Name, section, templateName)
+		if err != nil {
+			problems = append(problems, ThemeProblem{Template: templatePath, Err: err})
+			continue
+		}
+
+		// Look for references to undefined templates, which might not be
+		// found while executing since it's inside unvisited branch
+		for _, t := range tpl.Templates() {
+			if t.Tree == nil {
+				continue
+			}
+
+			for _, ref := range templateRefs(t.Tree.Root) {
+				if tpl.Lookup(ref) != nil {
+					continue
+				}
+
+				problemKey := t.Name() + "-" + ref
+				if _, reported := undefinedRefs[problemKey]; reported {
+					continue
+				}
+
+				undefinedRefs[problemKey] = struct{}{}
+				problems = append(problems, ThemeProblem{
+					Template: t.Name(),
+					Err:      fmt.Errorf("reference to undefined template %q", ref),
+				})
+			}
+		}
+
+		// HTML escaper refuses to execute template with undefined reference,
+		// so there is no need to execute it
+		if hasUndefinedRef(tpl) {
+			continue
+		}
+
+		// Execute template using synthetic data
+		for _, data := range dataList {
+			clone, err := wk.cloneTemplate(tpl)
+			if err != nil {
+				return nil, err
+			}
+
+			err = clone.Execute(io.Discard, data.Data)
+			if err != nil {
+				problems = append(problems, ThemeProblem{
+					Template: templatePath,
+					Data:     data.Name,
+					Err:      err,
+				})
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// pageTemplateNames returns names of page templates, mapped to synthetic data
+// that suitable for it. Beside the default names, it also looks for names that
+// specified in content metadata.
+func (wk *Worker) pageTemplateNames() (map[string][]syntheticData, error) {
+	dirData := syntheticDirData()
+	fileData := syntheticFileData()
+	tagFilesData := syntheticTagFilesData()
+	tagsData := syntheticTagsData()
+	termsData := syntheticTermsData()
+	termFilesData := syntheticTermFilesData()
+	archiveData := syntheticArchiveData()
+
+	names := map[string][]syntheticData{
+		"directory": dirData,
+		"file":      fileData,
+		"tagfiles":  tagFilesData,
+		"tags":      tagsData,
+		"terms":     termsData,
+		"termfiles": termFilesData,
+		"archive":   archiveData,
+	}
+
+	err := fp.WalkDir(wk.ContentDir, func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || fp.Ext(fPath) != ".md" {
+			return nil
+		}
+
+		meta, _, err := wk.parseMarkdown(fPath)
+		if err != nil {
+			return nil
+		}
+
+		if meta.DirTemplate != "" {
+			names[meta.DirTemplate] = dirData
+		}
+
+		if meta.FileTemplate != "" {
+			names[meta.FileTemplate] = fileData
+		}
+
+		if meta.TagFilesTemplate != "" {
+			names[meta.TagFilesTemplate] = tagFilesData
+		}
+
+		if meta.TagsTemplate != "" {
+			names[meta.TagsTemplate] = tagsData
+		}
+
+		if meta.ArchiveTemplate != "" {
+			names[meta.ArchiveTemplate] = archiveData
+		}
+
+		for _, tx := range meta.Taxonomies {
+			if tx.TermsTemplate != "" {
+				names[tx.TermsTemplate] = termsData
+			}
+
+			if tx.TermFilesTemplate != "" {
+				names[tx.TermFilesTemplate] = termFilesData
+			}
+		}
+
+		return nil
+	})
+
+	return names, err
+}
+
+// hasUndefinedRef checks whether the root template, or any template that
+// called by it, has reference to undefined template.
+func hasUndefinedRef(tpl *template.Template) bool {
+	visited := make(map[string]struct{})
+	queue := []string{tpl.Name()}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, exist := visited[name]; exist {
+			continue
+		}
+		visited[name] = struct{}{}
+
+		t := tpl.Lookup(name)
+		if t == nil {
+			return true
+		}
+
+		if t.Tree != nil {
+			queue = append(queue, templateRefs(t.Tree.Root)...)
+		}
+	}
+
+	return false
+}
+
+// templateRefs returns names of templates that called within the node.
+func templateRefs(node parse.Node) []string {
+	var refs []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, child := range n.Nodes {
+			refs = append(refs, templateRefs(child)...)
+		}
+	case *parse.IfNode:
+		refs = append(refs, templateRefs(n.List)...)
+		refs = append(refs, templateRefs(n.ElseList)...)
+	case *parse.RangeNode:
+		refs = append(refs, templateRefs(n.List)...)
+		refs = append(refs, templateRefs(n.ElseList)...)
+	case *parse.WithNode:
+		refs = append(refs, templateRefs(n.List)...)
+		refs = append(refs, templateRefs(n.ElseList)...)
+	case *parse.TemplateNode:
+		refs = append(refs, n.Name)
+	}
+
+	return refs
+}
+
+func syntheticDirData() []syntheticData {
+	now := time.Now()
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{IsDir: true, URLPath: "/blog", Title: "Blog"},
+	}
+
+	items := []model.ContentPath{
+		{IsDir: true, URLPath: "/blog/archive", Title: "Archive", NChild: 3},
+		{
+			URLPath:     "/blog/first-post",
+			Title:       "First Post",
+			Description: "Synthetic file",
+			Author:      "Boom",
+			CreateTime:  now.Add(-time.Hour),
+			UpdateTime:  now,
+			PublishTime: now.Add(time.Hour),
+			ExpiryTime:  now.Add(-time.Minute),
+			Tags:        []model.TagPath{{URLPath: "/blog/tag-go", Name: "go"}},
+			Cover:       "/cover.png",
+			Params:      map[string]interface{}{"key": "value"},
+			Draft:       true,
+			Summary:     "Synthetic summary",
+			WordCount:   250,
+			ReadingTime: 2,
+		},
+		{URLPath: "/blog/untimed-post", Title: "Untimed Post"},
+	}
+
+	langTag := model.TagPath{URLPath: "/blog/tag-lang", Name: "lang", Count: 2}
+	goTag := model.TagPath{URLPath: "/blog/tag-lang/go", Name: "lang/go", Count: 2, Parent: &langTag}
+	webTag := model.TagPath{URLPath: "/blog/tag-web", Name: "web", Count: 1}
+
+	tags := []model.TagPath{langTag, goTag, webTag}
+	tagTree := []model.TagPath{webTag, langTag}
+	tagTree[1].Children = []model.TagPath{goTag}
+
+	return []syntheticData{{
+		Name: "empty directory",
+		Data: model.DirData{
+			URLPath:     "/",
+			Title:       "Empty",
+			CurrentPage: 1,
+		},
+	}, {
+		Name: "single page directory",
+		Data: model.DirData{
+			URLPath:     "/blog",
+			PathTrails:  trails,
+			Title:       "Blog",
+			Description: "Synthetic directory",
+			Author:      "Boom",
+			Content:     template.HTML("<p>Synthetic content</p>"),
+			ChildItems:  items,
+			ChildTags:   tags,
+			TagTree:     tagTree,
+			Archive: []model.ArchiveGroup{{
+				URLPath: "/blog/2023",
+				Title:   "2023",
+				Year:    2023,
+				Files:   items[1:2],
+				Months: []model.ArchiveGroup{
+					{URLPath: "/blog/2023/03", Title: "March 2023", Year: 2023, Month: 3, Files: items[1:2]},
+				},
+			}},
+			CurrentPage: 1,
+			MaxPage:     1,
+		},
+	}, {
+		Name: "paginated directory",
+		Data: model.DirData{
+			URLPath:     "/blog/2",
+			PathTrails:  trails,
+			Title:       "Blog",
+			ChildItems:  items[1:],
+			ChildTags:   tags,
+			TagTree:     tagTree,
+			PageSize:    2,
+			CurrentPage: 2,
+			MaxPage:     3,
+		},
+	}}
+}
+
+func syntheticFileData() []syntheticData {
+	now := time.Now()
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{IsDir: true, URLPath: "/blog", Title: "Blog"},
+		{URLPath: "/blog/second-post", Title: "Second Post"},
+	}
+
+	toc := []model.TOCItem{
+		{Level: 2, ID: "intro", Text: "Intro", Children: []model.TOCItem{
+			{Level: 3, ID: "detail", Text: "Detail"},
+		}},
+		{Level: 2, Text: "Untitled"},
+	}
+
+	seriesItems := []model.ContentPath{
+		{URLPath: "/blog/first-post", Title: "First Post", Series: "Learn Go"},
+		{URLPath: "/blog/second-post", Title: "Second Post", Series: "Learn Go"},
+		{URLPath: "/docs/third-post", Title: "Third Post", Series: "Learn Go"},
+	}
+
+	return []syntheticData{{
+		Name: "minimal file",
+		Data: model.FileData{
+			URLPath: "/untitled",
+			Title:   "Untitled",
+		},
+	}, {
+		Name: "complete file",
+		Data: model.FileData{
+			URLPath:     "/blog/second-post",
+			PathTrails:  trails,
+			Title:       "Second Post",
+			Description: "Synthetic file",
+			Author:      "Boom",
+			CreateTime:  now.Add(-time.Hour),
+			UpdateTime:  now,
+			Cover:       "/cover.png",
+			Params:      map[string]interface{}{"key": "value"},
+			Content:     template.HTML("<p>Synthetic content</p>"),
+			TOC:         toc,
+			Summary:     template.HTML("<p>Synthetic summary</p>"),
+			WordCount:   3,
+			ReadingTime: 1,
+			Tags:        []model.TagPath{{URLPath: "/blog/tag-go", Name: "go"}},
+			Terms:       map[string][]model.TagPath{"categories": {{URLPath: "/categories/news", Name: "News"}}},
+			PrevFile:    model.ContentPath{URLPath: "/blog/first-post", Title: "First Post", UpdateTime: now},
+			NextFile:    model.ContentPath{URLPath: "/blog/third-post", Title: "Third Post"},
+		},
+	}, {
+		Name: "file in series",
+		Data: model.FileData{
+			URLPath:        "/blog/second-post",
+			PathTrails:     trails,
+			Title:          "Second Post",
+			Series:         "Learn Go",
+			SeriesItems:    seriesItems,
+			SeriesPosition: 2,
+			SeriesPrev:     seriesItems[0],
+			SeriesNext:     seriesItems[2],
+		},
+	}}
+}
+
+func syntheticTagFilesData() []syntheticData {
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{URLPath: "/tag-go", Title: "#go"},
+	}
+
+	files := []model.ContentPath{
+		{URLPath: "/first-post", Title: "First Post", UpdateTime: time.Now()},
+		{URLPath: "/untimed-post", Title: "Untimed Post"},
+	}
+
+	return []syntheticData{{
+		Name: "empty tag files",
+		Data: model.TagFilesData{
+			URLPath:     "/tag-go",
+			ActiveTag:   "go",
+			Title:       "Home",
+			CurrentPage: 1,
+		},
+	}, {
+		Name: "paginated tag files",
+		Data: model.TagFilesData{
+			URLPath:     "/tag-go/2",
+			PathTrails:  trails,
+			ActiveTag:   "go",
+			Title:       "Home",
+			Description: "Synthetic tag",
+			Content:     template.HTML("<p>Synthetic content</p>"),
+			Files:       files,
+			PageSize:    2,
+			CurrentPage: 2,
+			MaxPage:     3,
+		},
+	}}
+}
+
+func syntheticTagsData() []syntheticData {
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{URLPath: "/tags", Title: "Tags"},
+	}
+
+	return []syntheticData{{
+		Name: "empty tags",
+		Data: model.TagsData{
+			URLPath: "/tags",
+			Title:   "Tags",
+		},
+	}, {
+		Name: "site tags",
+		Data: model.TagsData{
+			URLPath:    "/tags",
+			PathTrails: trails,
+			Title:      "Tags",
+			Tags: []model.TagPath{
+				{URLPath: "/tags/go", Name: "go", Count: 2},
+				{URLPath: "/tags/web", Name: "web", Count: 1},
+				{URLPath: "/tags/web/css", Name: "web/css", Count: 1},
+			},
+			TagTree: []model.TagPath{
+				{URLPath: "/tags/go", Name: "go", Count: 2},
+				{URLPath: "/tags/web", Name: "web", Count: 1, Children: []model.TagPath{
+					{URLPath: "/tags/web/css", Name: "web/css", Count: 1},
+				}},
+			},
+		},
+	}}
+}
+
+func syntheticTermsData() []syntheticData {
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{URLPath: "/categories", Title: "Categories"},
+	}
+
+	return []syntheticData{{
+		Name: "empty terms",
+		Data: model.TermsData{
+			URLPath:  "/categories",
+			Taxonomy: "categories",
+			Title:    "Categories",
+		},
+	}, {
+		Name: "taxonomy terms",
+		Data: model.TermsData{
+			URLPath:    "/categories",
+			PathTrails: trails,
+			Taxonomy:   "categories",
+			Title:      "Categories",
+			Terms: []model.TagPath{
+				{URLPath: "/categories/news", Name: "News", Count: 2},
+				{URLPath: "/categories/release-notes", Name: "Release Notes", Count: 1},
+			},
+		},
+	}}
+}
+
+func syntheticTermFilesData() []syntheticData {
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{URLPath: "/categories", Title: "Categories"},
+		{URLPath: "/categories/news", Title: "News"},
+	}
+
+	files := []model.ContentPath{
+		{URLPath: "/first-post", Title: "First Post", UpdateTime: time.Now()},
+		{URLPath: "/untimed-post", Title: "Untimed Post"},
+	}
+
+	return []syntheticData{{
+		Name: "empty term files",
+		Data: model.TermFilesData{
+			URLPath:     "/categories/news",
+			Taxonomy:    "categories",
+			ActiveTerm:  "News",
+			Title:       "Categories",
+			CurrentPage: 1,
+		},
+	}, {
+		Name: "paginated term files",
+		Data: model.TermFilesData{
+			URLPath:     "/categories/news/2",
+			PathTrails:  trails,
+			Taxonomy:    "categories",
+			ActiveTerm:  "News",
+			Title:       "Categories",
+			Files:       files,
+			PageSize:    2,
+			CurrentPage: 2,
+			MaxPage:     3,
+		},
+	}}
+}
+
+func syntheticArchiveData() []syntheticData {
+	trails := []model.ContentPath{
+		{IsDir: true, URLPath: "/", Title: "Home"},
+		{IsDir: true, URLPath: "/blog", Title: "Blog"},
+		{URLPath: "/blog/2023", Title: "2023"},
+	}
+
+	createTime := time.Date(2023, time.March, 10, 0, 0, 0, 0, time.UTC)
+	files := []model.ContentPath{
+		{URLPath: "/blog/first-post", Title: "First Post", CreateTime: createTime},
+		{URLPath: "/blog/second-post", Title: "Second Post", CreateTime: createTime},
+	}
+
+	return []syntheticData{{
+		Name: "empty archive",
+		Data: model.ArchiveData{
+			URLPath:     "/blog/2023",
+			Title:       "Blog",
+			Period:      "2023",
+			Year:        2023,
+			CurrentPage: 1,
+		},
+	}, {
+		Name: "yearly archive",
+		Data: model.ArchiveData{
+			URLPath:    "/blog/2023",
+			PathTrails: trails,
+			Title:      "Blog",
+			Period:     "2023",
+			Year:       2023,
+			Months: []model.ArchiveGroup{
+				{URLPath: "/blog/2023/03", Title: "March 2023", Year: 2023, Month: 3, Files: files},
+			},
+			Files:       files,
+			PrevPeriod:  model.ContentPath{URLPath: "/blog/2022", Title: "2022"},
+			CurrentPage: 1,
+			MaxPage:     1,
+		},
+	}, {
+		Name: "paginated monthly archive",
+		Data: model.ArchiveData{
+			URLPath:     "/blog/2023/03/2",
+			PathTrails:  append(trails, model.ContentPath{URLPath: "/blog/2023/03", Title: "March"}),
+			Title:       "Blog",
+			Period:      "March 2023",
+			Year:        2023,
+			Month:       3,
+			Files:       files[1:],
+			PrevPeriod:  model.ContentPath{URLPath: "/blog/2023/01", Title: "January 2023"},
+			NextPeriod:  model.ContentPath{URLPath: "/blog/2023/05", Title: "May 2023"},
+			PageSize:    1,
+			CurrentPage: 2,
+			MaxPage:     2,
+		},
+	}}
+}
@@ -0,0 +1,287 @@
+package build
+
+import (
+	"fmt"
+	"path"
+	fp "path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// newContentPath creates ContentPath for content in specified URL path, using
+// its parsed metadata and content. For file, the tags are scoped to the dir
+// where the file lives, while for dir the tags are scoped to the dir itself.
+func (wk *Worker) newContentPath(urlPath string, isDir bool, meta model.Metadata, content renderedContent) model.ContentPath {
+	urlPath = path.Join("/", urlPath)
+	updateTime := meta.UpdateTime
+	if updateTime.IsZero() {
+		updateTime = meta.CreateTime
+	}
+
+	cp := model.ContentPath{
+		IsDir:       isDir,
+		URLPath:     urlPath,
+		Title:       meta.Title,
+		Description: meta.Description,
+		Author:      meta.Author,
+		CreateTime:  meta.CreateTime,
+		UpdateTime:  updateTime,
+		PublishTime: meta.PublishTime,
+		ExpiryTime:  meta.ExpiryTime,
+		Cover:       meta.Cover,
+		Params:      meta.Params,
+		Draft:       meta.Draft,
+		Hidden:      meta.Hidden,
+		Headless:    meta.Headless,
+		Weight:      meta.Weight,
+		Series:      strings.TrimSpace(meta.Series),
+	}
+
+	if !isDir {
+		cp.Summary = content.Summary
+		cp.WordCount = content.WordCount
+		cp.ReadingTime = content.ReadingTime
+	}
+
+	tagDir := urlPath
+	if !isDir {
+		tagDir = path.Dir(urlPath)
+	}
+
+	cp.Tags = wk.tagPaths(tagDir, meta.Tags)
+
+	return cp
+}
+
+// isListed checks whether content with the metadata is listed in directory
+// listing, tag pages and the other generated indexes. Unpublished content is
+// not listed, while hidden and headless content are never listed.
+func (wk *Worker) isListed(meta model.Metadata) bool {
+	return wk.isPublished(meta) && !meta.Hidden && !meta.Headless
+}
+
+// rawMeta returns metadata of the markdown file as it's written, without the
+// fields inherited from its parents. Since it's read for every directory in
+// every walk and for every parent of parsed file, the result is cached when
+// cache is enabled.
+func (wk *Worker) rawMeta(mdPath string) (model.Metadata, error) {
+	if wk.cacheEnabled {
+		if meta, cached := wk.rawMetaCache[mdPath]; cached {
+			return meta, nil
+		}
+	}
+
+	meta, _, err := wk.parseMarkdown(mdPath)
+	if err != nil {
+		return meta, err
+	}
+
+	if wk.cacheEnabled {
+		wk.rawMetaCache[mdPath] = meta
+	}
+
+	return meta, nil
+}
+
+// isUnlistedDir checks whether content of the directory should be skipped
+// while walking its ancestor. Hidden directory is always skipped, while
+// headless directory is only skipped if withHeadless is false.
+func (wk *Worker) isUnlistedDir(dirPath string, withHeadless bool) bool {
+	meta, err := wk.rawMeta(fp.Join(dirPath, "_index.md"))
+	return err == nil && (meta.Hidden || (meta.Headless && !withHeadless))
+}
+
+// isHeadless checks whether the markdown file is headless, either by its own
+// metadata or because it's inside a headless directory.
+func (wk *Worker) isHeadless(mdPath string) bool {
+	meta, err := wk.rawMeta(mdPath)
+	if err == nil && meta.Headless {
+		return true
+	}
+
+	for dir := fp.Dir(mdPath); dir != wk.ContentDir && dir != fp.Dir(dir); dir = fp.Dir(dir) {
+		dirMeta, err := wk.rawMeta(fp.Join(dir, "_index.md"))
+		if err == nil && dirMeta.Headless {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultSortOrder returns the default order for sort key. Dates are sorted
+// from the newest one, while the others are sorted ascending.
+func defaultSortOrder(key string) string {
+	switch strings.ToLower(key) {
+	case "date", "createtime":
+		return "desc"
+	default:
+		return "asc"
+	}
+}
+
+// dirSortOrder returns key and order for sorting items in a directory, which
+// specified in its metadata. If it's not specified, sub directories are sorted
+// by its title while files are sorted from the newest one.
+func dirSortOrder(meta model.Metadata, isDir bool) (key string, order string) {
+	switch {
+	case meta.SortBy != "":
+		return meta.SortBy, meta.SortOrder
+	case isDir:
+		return "title", "asc"
+	default:
+		return "date", "desc"
+	}
+}
+
+// sortContentPaths sorts items by the specified key, which could be `date`,
+// `createTime`, `title`, `weight` or `filename`, in `asc` or `desc` order.
+// If order is empty, the default order of the key is used. Items with the
+// same value are sorted by its title. When sorted by weight, items without
+// weight are always put after the weighted ones.
+func sortContentPaths(items []model.ContentPath, key string, order string) ([]model.ContentPath, error) {
+	var compare func(a, b model.ContentPath) int
+	switch strings.ToLower(key) {
+	case "date":
+		compare = func(a, b model.ContentPath) int {
+			return compareTime(a.UpdateTime, b.UpdateTime)
+		}
+	case "createtime":
+		compare = func(a, b model.ContentPath) int {
+			return compareTime(a.CreateTime, b.CreateTime)
+		}
+	case "title":
+		compare = func(a, b model.ContentPath) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		}
+	case "weight":
+		compare = func(a, b model.ContentPath) int {
+			return a.Weight - b.Weight
+		}
+	case "filename":
+		compare = func(a, b model.ContentPath) int {
+			return strings.Compare(path.Base(a.URLPath), path.Base(b.URLPath))
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort key %s", key)
+	}
+
+	if order == "" {
+		order = defaultSortOrder(key)
+	}
+
+	descending := false
+	switch strings.ToLower(order) {
+	case "asc":
+	case "desc":
+		descending = true
+	default:
+		return nil, fmt.Errorf("unknown sort order %s", order)
+	}
+
+	unweightedLast := strings.ToLower(key) == "weight"
+	result := append([]model.ContentPath{}, items...)
+	sort.SliceStable(result, func(a, b int) bool {
+		if unweightedLast && (result[a].Weight == 0) != (result[b].Weight == 0) {
+			return result[b].Weight == 0
+		}
+
+		cmp := compare(result[a], result[b])
+		if descending {
+			cmp = -cmp
+		}
+
+		if cmp != 0 {
+			return cmp < 0
+		}
+
+		titleA := strings.ToLower(result[a].Title)
+		titleB := strings.ToLower(result[b].Title)
+		return titleA < titleB
+	})
+
+	return result, nil
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// seriesItems returns files in the entire site that belong to the series.
+// The series name is compared by its slug, and the files are sorted by its
+// weight, then by its creation time. Since it's needed by every file in the
+// series, every series is indexed at once and cached when cache is enabled.
+func (wk *Worker) seriesItems(series string) ([]model.ContentPath, error) {
+	seriesSlug := slugify(series)
+	if wk.cacheEnabled {
+		if items, cached := wk.seriesCache[seriesSlug]; cached {
+			return items, nil
+		}
+	}
+
+	index, err := wk.seriesIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	items := index[seriesSlug]
+	if items == nil {
+		items = []model.ContentPath{}
+	}
+
+	// Series without any listed file is cached as well, so it's not
+	// indexed again
+	if wk.cacheEnabled {
+		for slug, slugItems := range index {
+			wk.seriesCache[slug] = slugItems
+		}
+		wk.seriesCache[seriesSlug] = items
+	}
+
+	return items, nil
+}
+
+// seriesIndex groups files in the entire site by slug of its series, sorted
+// in the order of the series.
+func (wk *Worker) seriesIndex() (map[string][]model.ContentPath, error) {
+	allFiles, err := wk.listPages("/", true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string][]model.ContentPath)
+	for _, file := range allFiles {
+		if file.Series != "" {
+			slug := slugify(file.Series)
+			index[slug] = append(index[slug], file)
+		}
+	}
+
+	for _, items := range index {
+		sort.SliceStable(items, func(a, b int) bool {
+			if items[a].Weight != items[b].Weight {
+				return items[a].Weight < items[b].Weight
+			}
+
+			if cmp := compareTime(items[a].CreateTime, items[b].CreateTime); cmp != 0 {
+				return cmp < 0
+			}
+
+			titleA := strings.ToLower(items[a].Title)
+			titleB := strings.ToLower(items[b].Title)
+			return titleA < titleB
+		})
+	}
+
+	return index, nil
+}
@@ -0,0 +1,92 @@
+package build
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+func TestSortContentPathsByWeight(t *testing.T) {
+	items := []model.ContentPath{
+		{Title: "Zero B"},
+		{Title: "Two", Weight: 2},
+		{Title: "Zero A"},
+		{Title: "One", Weight: 1},
+		{Title: "Three", Weight: 3},
+	}
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{"asc", []string{"One", "Two", "Three", "Zero A", "Zero B"}},
+		{"desc", []string{"Three", "Two", "One", "Zero A", "Zero B"}},
+		{"", []string{"One", "Two", "Three", "Zero A", "Zero B"}},
+	}
+
+	for _, test := range tests {
+		result, err := sortContentPaths(items, "weight", test.order)
+		if err != nil {
+			t.Errorf("sort by weight %q: unexpected error: %v", test.order, err)
+			continue
+		}
+
+		titles := []string{}
+		for _, item := range result {
+			titles = append(titles, item.Title)
+		}
+
+		if !reflect.DeepEqual(titles, test.expected) {
+			t.Errorf("sort by weight %q: expected %v, got %v", test.order, test.expected, titles)
+		}
+	}
+}
+
+func TestSeriesItems(t *testing.T) {
+	files := map[string]string{
+		"blog/_index.md":        "+++\nTitle = \"Blog\"\n+++\n",
+		"blog/part-2.md":        "+++\nTitle = \"Part 2\"\nSeries = \"Go Basics\"\nCreateTime = 2023-01-02T00:00:00Z\n+++\n",
+		"blog/part-1.md":        "+++\nTitle = \"Part 1\"\nSeries = \"go basics\"\nCreateTime = 2023-01-01T00:00:00Z\n+++\n",
+		"blog/intro.md":         "+++\nTitle = \"Intro\"\nSeries = \"Go Basics\"\nWeight = -1\n+++\n",
+		"blog/secret.md":        "+++\nTitle = \"Secret\"\nSeries = \"Go Basics\"\nHidden = true\n+++\n",
+		"docs/_index.md":        "+++\nTitle = \"Docs\"\n+++\n",
+		"docs/appendix.md":      "+++\nTitle = \"Appendix\"\nSeries = \"Go Basics\"\nWeight = 1\n+++\n",
+		"docs/other.md":         "+++\nTitle = \"Other\"\nSeries = \"Rust\"\n+++\n",
+		"docs/hidden/_index.md": "+++\nTitle = \"Hidden\"\nHidden = true\n+++\n",
+		"docs/hidden/only.md":   "+++\nTitle = \"Only\"\nSeries = \"Secret Series\"\n+++\n",
+	}
+
+	tests := []struct {
+		series   string
+		expected []string
+	}{
+		{"Go Basics", []string{"Intro", "Part 1", "Part 2", "Appendix"}},
+		{"GO-BASICS", []string{"Intro", "Part 1", "Part 2", "Appendix"}},
+		{"Rust", []string{"Other"}},
+		{"Secret Series", []string{}},
+	}
+
+	for _, cacheEnabled := range []bool{false, true} {
+		wk := newTestWorker(t, files)
+		wk.cacheEnabled = cacheEnabled
+
+		for _, test := range tests {
+			items, err := wk.seriesItems(test.series)
+			if err != nil {
+				t.Errorf("series %q: unexpected error: %v", test.series, err)
+				continue
+			}
+
+			titles := []string{}
+			for _, item := range items {
+				titles = append(titles, item.Title)
+			}
+
+			if !reflect.DeepEqual(titles, test.expected) {
+				t.Errorf("series %q with cache %v: expected %v, got %v",
+					test.series, cacheEnabled, test.expected, titles)
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	fp "path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func makeDict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("requires pairs of key and value")
+	}
+
+	dict := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, isString := pairs[i].(string)
+		if !isString {
+			return nil, fmt.Errorf("key must be a string, got %T", pairs[i])
+		}
+		dict[key] = pairs[i+1]
+	}
+
+	return dict, nil
+}
+
+// maxSeqLength is the maximum count of numbers that created by `seq`.
+const maxSeqLength = 10000
+
+func makeList(items ...interface{}) []interface{} {
+	return items
+}
+
+// makeSlice creates a list from the items, the same as makeList. However, to
+// keep the built-in `slice` working, string or slice that followed by one to
+// three indexes is sliced instead, e.g. `slice "abcdef" 1 3` returns "bc".
+func makeSlice(items ...interface{}) (interface{}, error) {
+	if len(items) < 2 || len(items) > 4 {
+		return makeList(items...), nil
+	}
+
+	value := reflect.ValueOf(items[0])
+	if kind := value.Kind(); kind != reflect.String && kind != reflect.Slice {
+		return makeList(items...), nil
+	}
+
+	indexes := []int{}
+	for _, item := range items[1:] {
+		idx, isInt := item.(int)
+		if !isInt {
+			return makeList(items...), nil
+		}
+		indexes = append(indexes, idx)
+	}
+
+	// Slice the value, with the same rules as the built-in
+	start, end := indexes[0], value.Len()
+	if len(indexes) > 1 {
+		end = indexes[1]
+	}
+
+	if start < 0 || end < start || end > value.Len() {
+		return nil, fmt.Errorf("index out of range: %d:%d", start, end)
+	}
+
+	if len(indexes) < 3 {
+		return value.Slice(start, end).Interface(), nil
+	}
+
+	max := indexes[2]
+	if value.Kind() == reflect.String {
+		return nil, errors.New("cannot 3-index slice a string")
+	}
+
+	if max < end || max > value.Cap() {
+		return nil, fmt.Errorf("index out of range: %d:%d:%d", start, end, max)
+	}
+
+	return value.Slice3(start, end, max).Interface(), nil
+}
+
+func makeSeq(args ...int) ([]int, error) {
+	var start, end int
+	switch len(args) {
+	case 1:
+		// Sequence from 1 to zero or negative number is empty
+		start, end = 1, args[0]
+		if end < 1 {
+			return []int{}, nil
+		}
+	case 2:
+		start, end = args[0], args[1]
+	default:
+		return nil, errors.New("requires one or two numbers")
+	}
+
+	// Make the sequence, which might be descending
+	step := 1
+	length := end - start + 1
+	if start > end {
+		step = -1
+		length = start - end + 1
+	}
+
+	if length > maxSeqLength {
+		return nil, fmt.Errorf("sequence can't be longer than %d numbers", maxSeqLength)
+	}
+
+	seq := make([]int, 0, length)
+	for i := start; ; i += step {
+		seq = append(seq, i)
+		if i == end {
+			break
+		}
+	}
+
+	return seq, nil
+}
+
+// convertInt converts number or numeric string into integer. Float is rounded
+// toward zero.
+func convertInt(value interface{}) (int, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), nil
+	case reflect.String:
+		if i, err := strconv.Atoi(strings.TrimSpace(v.String())); err == nil {
+			return i, nil
+		}
+	}
+
+	f, err := convertFloat(value)
+	return int(f), err
+}
+
+// convertFloat converts number or numeric string into float.
+func convertFloat(value interface{}) (float64, error) {
+	if str, isString := value.(string); isString {
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", str)
+		}
+		return f, nil
+	}
+
+	if f, isNumber := toFloat(value); isNumber {
+		return f, nil
+	}
+
+	return 0, fmt.Errorf("can't convert %T into number", value)
+}
+
+func defaultValue(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 {
+		return def
+	}
+
+	if truth, ok := template.IsTrue(value[0]); !ok || !truth {
+		return def
+	}
+
+	return value[0]
+}
+
+// readFile returns content of file in specified path, relative to the root dir
+// of the site. The file is not allowed to be outside the root dir.
+func (wk Worker) readFile(filePath string) (string, error) {
+	rootDir, err := fp.EvalSymlinks(wk.RootDir)
+	if err != nil {
+		return "", err
+	}
+
+	fullPath, err := fp.EvalSymlinks(fp.Join(rootDir, fp.Clean("/"+filePath)))
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := fp.Rel(rootDir, fullPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(fp.Separator)) {
+		return "", fmt.Errorf("%s is outside the site", filePath)
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// includeFunc returns function that renders partial from tpl. Since the
+// template is not available when creating function map, the `include` in
+// function map doesn't have template and must be replaced after the
+// template created.
+func includeFunc(tpl *template.Template) func(string, ...interface{}) (template.HTML, error) {
+	return func(name string, args ...interface{}) (template.HTML, error) {
+		if tpl == nil {
+			return "", errors.New("partials are not available")
+		}
+
+		// Find the partial, which extension is optional
+		partial := tpl.Lookup(name)
+		if partial == nil && fp.Ext(name) != ".html" {
+			name += ".html"
+			partial = tpl.Lookup(name)
+		}
+
+		if partial == nil {
+			return "", fmt.Errorf("partial %s doesn't exist", name)
+		}
+
+		// Prepare data for the partial
+		var data interface{}
+		switch {
+		case len(args) == 1:
+			data = args[0]
+		case len(args) > 1:
+			dict, err := makeDict(args...)
+			if err != nil {
+				return "", err
+			}
+			data = dict
+		}
+
+		buf := bytes.NewBuffer(nil)
+		if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+
+		return template.HTML(buf.String()), nil
+	}
+}
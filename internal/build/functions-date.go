@@ -0,0 +1,63 @@
+package build
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeUnits is units that used by timeAgo, from the largest one.
+var timeUnits = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+}
+
+func timeNow() time.Time {
+	return time.Now()
+}
+
+func dateFormat(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	// Check if the time is in the future
+	diff := time.Since(t)
+	isFuture := diff < 0
+	if isFuture {
+		diff = -diff
+	}
+
+	// Find the largest unit that fits
+	for _, unit := range timeUnits {
+		n := int(diff / unit.Duration)
+		if n < 1 {
+			continue
+		}
+
+		text := fmt.Sprintf("%d %s", n, unit.Name)
+		if n > 1 {
+			text += "s"
+		}
+
+		if isFuture {
+			return "in " + text
+		}
+		return text + " ago"
+	}
+
+	return "just now"
+}
@@ -0,0 +1,141 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	fp "path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func titleCase(str string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(prev) || prev == '-' {
+			prev = r
+			return unicode.ToTitle(r)
+		}
+
+		prev = r
+		return r
+	}, str)
+}
+
+func truncate(length int, text interface{}) string {
+	// Convert HTML into plain text
+	var str string
+	if html, isHTML := text.(template.HTML); isHTML {
+		str = plainText([]byte(html))
+	} else {
+		str = fmt.Sprint(text)
+	}
+
+	str = strings.TrimSpace(str)
+	if length <= 0 || utf8.RuneCountInString(str) <= length {
+		return str
+	}
+
+	// Cut the text, then move back to the last whitespace
+	// so the last word is not cut in half
+	runes := []rune(str)
+	cut := length
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+
+	if cut == 0 {
+		cut = length
+	}
+
+	return strings.TrimSpace(string(runes[:cut])) + "…"
+}
+
+func strReplace(old, new string, str string) string {
+	return strings.ReplaceAll(str, old, new)
+}
+
+func strSplit(sep string, str string) []string {
+	return strings.Split(str, sep)
+}
+
+func strJoin(sep string, list interface{}) (string, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("can't join %T", list)
+	}
+
+	items := make([]string, value.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+
+	return strings.Join(items, sep), nil
+}
+
+func strContains(substr string, str string) bool {
+	return strings.Contains(str, substr)
+}
+
+func strHasPrefix(prefix string, str string) bool {
+	return strings.HasPrefix(str, prefix)
+}
+
+func strHasSuffix(suffix string, str string) bool {
+	return strings.HasSuffix(str, suffix)
+}
+
+// markdownify converts markdown text into HTML using markdown config of the
+// site. Render hooks and shortcodes are not used here.
+func (wk Worker) markdownify(text interface{}) (template.HTML, error) {
+	rootIndex := fp.Join(wk.ContentDir, "_index.md")
+	meta, _, err := wk.parseMarkdown(rootIndex)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := convertMarkdownToHTML([]byte(fmt.Sprint(text)), meta.Markdown, nil, nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	// If the result is only a single paragraph, unwrap it
+	// so it can be used in inline element
+	html := strings.TrimSpace(string(result.HTML))
+	if strings.HasPrefix(html, "<p>") && strings.HasSuffix(html, "</p>") &&
+		strings.Count(html, "<p>") == 1 {
+		html = strings.TrimSuffix(strings.TrimPrefix(html, "<p>"), "</p>")
+	}
+
+	return template.HTML(html), nil
+}
+
+func plainify(text interface{}) string {
+	return plainText([]byte(fmt.Sprint(text)))
+}
+
+func safeHTML(str string) template.HTML {
+	return template.HTML(str)
+}
+
+func safeURL(str string) template.URL {
+	return template.URL(str)
+}
+
+func safeCSS(str string) template.CSS {
+	return template.CSS(str)
+}
+
+func safeJS(str string) template.JS {
+	return template.JS(str)
+}
+
+func jsonify(value interface{}) (template.JS, error) {
+	bt, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(bt), nil
+}
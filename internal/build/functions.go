@@ -1,20 +1,140 @@
 package build
 
+//go:generate go run ../.. theme functions --output ../../docs/template-functions.md
+
 import (
+	"errors"
+	"fmt"
 	"html/template"
 	"path"
 	fp "path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/RadhiFadlillah/boom/internal/fileutils"
 )
 
+// templateFunction is a function that available in template, along with its
+// documentation which used for generating the function reference.
+type templateFunction struct {
+	Category    string
+	Name        string
+	Usage       string
+	Description string
+	Func        interface{}
+}
+
+func (wk Worker) templateFunctions() []templateFunction {
+	return []templateFunction{
+		// Math
+		{"Math", "add", "add A B", "Returns A + B.", mathAdd},
+		{"Math", "sub", "sub A B", "Returns A - B.", mathSub},
+		{"Math", "mul", "mul A B", "Returns A * B.", mathMul},
+		{"Math", "div", "div A B", "Returns A / B, rounded toward zero. Fails if B is zero.", mathDiv},
+		{"Math", "mod", "mod A B", "Returns the remainder of A / B. Fails if B is zero.", mathMod},
+		{"Math", "min", "min A B...", "Returns the smallest number.", mathMin},
+		{"Math", "max", "max A B...", "Returns the largest number.", mathMax},
+
+		// Date
+		{"Date", "now", "now", "Returns the current time.", timeNow},
+		{"Date", "dateFormat", "dateFormat LAYOUT TIME", "Formats the time using Go layout, e.g. `dateFormat \"2 January 2006\" .CreateTime`. Returns empty string for zero time.", dateFormat},
+		{"Date", "timeAgo", "timeAgo TIME", "Returns the time relative to now, e.g. `3 days ago` or `in 2 hours`. Returns empty string for zero time.", timeAgo},
+
+		// String
+		{"String", "upper", "upper STRING", "Converts the string to upper case.", strings.ToUpper},
+		{"String", "lower", "lower STRING", "Converts the string to lower case.", strings.ToLower},
+		{"String", "title", "title STRING", "Capitalizes the first letter of each word.", titleCase},
+		{"String", "trim", "trim STRING", "Removes leading and trailing whitespaces.", strings.TrimSpace},
+		{"String", "truncate", "truncate LENGTH TEXT", "Shortens the text to at most LENGTH characters without cutting words, ended with ellipsis. HTML is converted into plain text first.", truncate},
+		{"String", "slugify", "slugify STRING", "Converts the string into URL friendly slug, e.g. `Hello, World` into `hello-world`.", slugify},
+		{"String", "replace", "replace OLD NEW STRING", "Replaces every OLD in the string with NEW.", strReplace},
+		{"String", "split", "split SEPARATOR STRING", "Splits the string into list of string.", strSplit},
+		{"String", "join", "join SEPARATOR LIST", "Joins the items in list into a string.", strJoin},
+		{"String", "contains", "contains SUBSTRING STRING", "Checks whether the string contains the substring.", strContains},
+		{"String", "hasPrefix", "hasPrefix PREFIX STRING", "Checks whether the string starts with the prefix.", strHasPrefix},
+		{"String", "hasSuffix", "hasSuffix SUFFIX STRING", "Checks whether the string ends with the suffix.", strHasSuffix},
+
+		// HTML
+		{"HTML", "markdownify", "markdownify TEXT", "Renders the markdown text into HTML using site wide markdown options. Single paragraph is not wrapped in `<p>`.", wk.markdownify},
+		{"HTML", "plainify", "plainify HTML", "Removes HTML tags from the content.", plainify},
+		{"HTML", "safeHTML", "safeHTML STRING", "Marks the string as safe HTML, so it will not be escaped.", safeHTML},
+		{"HTML", "safeURL", "safeURL STRING", "Marks the string as safe URL, so it will not be escaped.", safeURL},
+		{"HTML", "safeCSS", "safeCSS STRING", "Marks the string as safe CSS, so it will not be escaped.", safeCSS},
+		{"HTML", "safeJS", "safeJS STRING", "Marks the string as safe JavaScript, so it will not be escaped.", safeJS},
+		{"HTML", "jsonify", "jsonify VALUE", "Encodes the value into JSON.", jsonify},
+
+		// Data
+		{"Data", "dict", "dict KEY VALUE...", "Creates a map from pairs of key and value. Key must be a string.", makeDict},
+		{"Data", "list", "list VALUE...", "Creates a list from the values, e.g. for building data with `dict`.", makeList},
+		{"Data", "slice", "slice VALUE...", "Creates a list from the values, the same as `list`. To keep the built-in `slice` working, a string or list that followed by one to three indexes is sliced instead, e.g. `slice \"abcdef\" 1 3` returns `bc`.", makeSlice},
+		{"Data", "seq", "seq LAST | seq FIRST LAST", "Creates list of integers from 1 (or FIRST) to LAST. `seq LAST` is empty if LAST is less than 1. The list can't be longer than 10000 numbers.", makeSeq},
+		{"Data", "int", "int VALUE", "Converts number or numeric string into integer, e.g. `first (int .Params.count) .ChildItems`. Float is rounded toward zero.", convertInt},
+		{"Data", "float", "float VALUE", "Converts number or numeric string into float.", convertFloat},
+		{"Data", "default", "default DEFAULT VALUE", "Returns VALUE if it's not empty, otherwise returns DEFAULT, e.g. `.Description | default \"No description\"`.", defaultValue},
+		{"Data", "readFile", "readFile PATH", "Returns content of the file. PATH is relative to the site's root dir, and the file must be inside it.", wk.readFile},
+		{"Data", "include", "include NAME [DATA | KEY VALUE...]", "Renders the partial with specified name. The data for partial could be a single value, or pairs of key and value that combined into a map.", includeFunc(nil)},
+
+		// Page
+		{"Page", "paginationLink", "paginationLink URLPATH NUMBER", "Returns URL for the page number of the current page.", wk.paginationLink},
+		{"Page", "getPage", "getPage URLPATH", "Returns the file or directory in the URL path as `ContentPath`. If it doesn't exist, its `URLPath` will be empty.", wk.getPage},
+		{"Page", "pages", "pages URLPATH", "Returns files inside the directory, sorted by the sort order of the directory.", wk.pages},
+		{"Page", "pagesRecursive", "pagesRecursive URLPATH", "Returns files inside the directory and all of its sub directories, sorted by the sort order of the directory.", wk.pagesRecursive},
+		{"Page", "tagged", "tagged TAG [PAGES]", "Returns files that use the tag, either in the entire site or within PAGES.", wk.tagged},
+		{"Page", "where", "where PAGES FIELD [OPERATOR] VALUE", "Filters pages whose field matches the value. Operator could be `=` (the default), `!=`, `<`, `<=`, `>`, `>=`, `in`, `not in` or `contains`.", where},
+		{"Page", "sortBy", "sortBy PAGES FIELD [ORDER]", "Sorts pages by the field. Order is `asc` (the default) or `desc`.", sortBy},
+		{"Page", "first", "first N PAGES", "Returns the first N pages.", first},
+		{"Page", "last", "last N PAGES", "Returns the last N pages.", last},
+		{"Page", "groupByYear", "groupByYear PAGES", "Groups pages by the year of `CreateTime` into list of `PageGroup`, sorted from the newest year.", groupByYear},
+	}
+}
+
 func (wk Worker) funcMap() template.FuncMap {
-	return template.FuncMap{
-		"add":            mathAdd,
-		"sub":            mathSub,
-		"paginationLink": wk.paginationLink,
+	funcMap := template.FuncMap{}
+	for _, fn := range wk.templateFunctions() {
+		funcMap[fn.Name] = fn.Func
+	}
+	return funcMap
+}
+
+// cloneTemplate clones the cached template then binds template functions into
+// the clone, so it uses the current worker and the cached template that shared
+// between renders is never modified. Since template can't be cloned after it's
+// executed, only the clone should be executed.
+func (wk Worker) cloneTemplate(tpl *template.Template) (*template.Template, error) {
+	clone, err := tpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	clone.Funcs(wk.funcMap())
+	clone.Funcs(template.FuncMap{"include": includeFunc(clone)})
+	return clone, nil
+}
+
+// FunctionReference returns reference of template functions as markdown.
+func FunctionReference() string {
+	var sb strings.Builder
+	sb.WriteString("<!-- Code generated by `boom theme functions`. DO NOT EDIT. -->\n\n")
+	sb.WriteString("# Template Functions\n\n")
+	sb.WriteString("Beside the [built-in functions](https://pkg.go.dev/text/template#hdr-Functions) ")
+	sb.WriteString("like `eq`, `lt`, `and` and `len`, these functions are available in every template.\n")
+
+	category := ""
+	for _, fn := range (Worker{}).templateFunctions() {
+		if fn.Category != category {
+			category = fn.Category
+			sb.WriteString("\n## " + category + "\n\n")
+			sb.WriteString("| Function | Description |\n")
+			sb.WriteString("| --- | --- |\n")
+		}
+
+		// Pipe is escaped since it's used as table separator
+		usage := strings.ReplaceAll(fn.Usage, "|", "\\|")
+		description := strings.ReplaceAll(fn.Description, "|", "\\|")
+		sb.WriteString(fmt.Sprintf("| `%s` | %s |\n", usage, description))
 	}
+
+	return sb.String()
 }
 
 func mathAdd(a, b int) int {
@@ -25,7 +145,49 @@ func mathSub(a, b int) int {
 	return a - b
 }
 
+func mathMul(a, b int) int {
+	return a * b
+}
+
+func mathDiv(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+
+func mathMod(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a % b, nil
+}
+
+func mathMin(a int, others ...int) int {
+	for _, b := range others {
+		if b < a {
+			a = b
+		}
+	}
+	return a
+}
+
+func mathMax(a int, others ...int) int {
+	for _, b := range others {
+		if b > a {
+			a = b
+		}
+	}
+	return a
+}
+
 func (wk Worker) paginationLink(currentPath string, pageNumber int) string {
+	// Page number of archive is put after its period, e.g. /blog/2023/2
+	if dirURLPath, year, month, _, ok := wk.archiveOf(strings.Trim(currentPath, "/")); ok {
+		period := newArchiveGroup(dirURLPath, year, month)
+		return path.Join(period.URLPath, strconv.Itoa(pageNumber))
+	}
+
 	for {
 		isNum, _ := isNumber(path.Base(currentPath))
 		fPath := fp.Join(wk.ContentDir, currentPath+".md")
@@ -0,0 +1,538 @@
+package build
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	fp "path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		length   int
+		text     interface{}
+		expected string
+	}{
+		{10, "Hello world, foo", "Hello…"},
+		{20, "Hello world", "Hello world"},
+		{0, "Hello world", "Hello world"},
+		{3, "abcdef", "abc…"},
+		{4, "héllo wörld", "héll…"},
+		{7, "  Hello world  ", "Hello…"},
+		{20, template.HTML("<p>Hello <b>world</b></p>"), "Hello world"},
+		{5, 1234567, "12345…"},
+	}
+
+	for _, test := range tests {
+		if result := truncate(test.length, test.text); result != test.expected {
+			t.Errorf("truncate(%d, %q): expected %q, got %q", test.length, test.text, test.expected, result)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected string
+	}{
+		{"Hello, World", "hello-world"},
+		{"  C# & Go  ", "c-go"},
+		{"Web Dev", "web-dev"},
+		{"Ünïcode 123", "ünïcode-123"},
+		{"already-a-slug", "already-a-slug"},
+		{"---", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if result := slugify(test.str); result != test.expected {
+			t.Errorf("slugify(%q): expected %q, got %q", test.str, test.expected, result)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected string
+	}{
+		{"hello world", "Hello World"},
+		{"foo-bar baz", "Foo-Bar Baz"},
+		{"already Title", "Already Title"},
+		{"élan vital", "Élan Vital"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if result := titleCase(test.str); result != test.expected {
+			t.Errorf("titleCase(%q): expected %q, got %q", test.str, test.expected, result)
+		}
+	}
+}
+
+func TestDateFormat(t *testing.T) {
+	date := time.Date(2023, time.March, 5, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		layout   string
+		time     time.Time
+		expected string
+	}{
+		{"2 January 2006", date, "5 March 2023"},
+		{"2006-01-02 15:04", date, "2023-03-05 14:30"},
+		{"Jan 2006", date, "Mar 2023"},
+		{"2 January 2006", time.Time{}, ""},
+	}
+
+	for _, test := range tests {
+		if result := dateFormat(test.layout, test.time); result != test.expected {
+			t.Errorf("dateFormat(%q, %v): expected %q, got %q", test.layout, test.time, test.expected, result)
+		}
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		time     time.Time
+		expected string
+	}{
+		{time.Time{}, ""},
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-5 * time.Minute), "5 minutes ago"},
+		{now.Add(-time.Hour), "1 hour ago"},
+		{now.Add(-3 * 24 * time.Hour), "3 days ago"},
+		{now.Add(-14 * 24 * time.Hour), "2 weeks ago"},
+		{now.Add(-400 * 24 * time.Hour), "1 year ago"},
+		{now.Add(2*time.Hour + 30*time.Second), "in 2 hours"},
+		{now.Add(24*time.Hour + 30*time.Second), "in 1 day"},
+	}
+
+	for _, test := range tests {
+		if result := timeAgo(test.time); result != test.expected {
+			t.Errorf("timeAgo(%v): expected %q, got %q", test.time, test.expected, result)
+		}
+	}
+}
+
+func TestMakeSeq(t *testing.T) {
+	tests := []struct {
+		args     []int
+		expected []int
+		isError  bool
+	}{
+		{[]int{3}, []int{1, 2, 3}, false},
+		{[]int{2, 4}, []int{2, 3, 4}, false},
+		{[]int{3, 1}, []int{3, 2, 1}, false},
+		{[]int{5, 5}, []int{5}, false},
+		{[]int{0}, []int{}, false},
+		{[]int{-2}, []int{}, false},
+		{[]int{-1, 1}, []int{-1, 0, 1}, false},
+		{[]int{maxSeqLength}, nil, false},
+		{[]int{maxSeqLength + 1}, nil, true},
+		{[]int{1, -maxSeqLength}, nil, true},
+		{[]int{}, nil, true},
+		{[]int{1, 2, 3}, nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := makeSeq(test.args...)
+		if (err != nil) != test.isError {
+			t.Errorf("seq%v: expected error %v, got %v", test.args, test.isError, err)
+			continue
+		}
+
+		if test.isError || test.expected == nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("seq%v: expected %v, got %v", test.args, test.expected, result)
+		}
+	}
+}
+
+func TestMakeDict(t *testing.T) {
+	tests := []struct {
+		pairs    []interface{}
+		expected map[string]interface{}
+		isError  bool
+	}{
+		{[]interface{}{"a", 1, "b", "x"}, map[string]interface{}{"a": 1, "b": "x"}, false},
+		{[]interface{}{}, map[string]interface{}{}, false},
+		{[]interface{}{"a"}, nil, true},
+		{[]interface{}{1, "a"}, nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := makeDict(test.pairs...)
+		if (err != nil) != test.isError {
+			t.Errorf("dict%v: expected error %v, got %v", test.pairs, test.isError, err)
+			continue
+		}
+
+		if !test.isError && !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("dict%v: expected %v, got %v", test.pairs, test.expected, result)
+		}
+	}
+}
+
+func TestMakeList(t *testing.T) {
+	tests := []struct {
+		items    []interface{}
+		expected []interface{}
+	}{
+		{[]interface{}{1, "x", true}, []interface{}{1, "x", true}},
+		{[]interface{}{"a"}, []interface{}{"a"}},
+		{[]interface{}{}, []interface{}{}},
+	}
+
+	for _, test := range tests {
+		result := makeList(test.items...)
+		if len(result) != len(test.expected) || (len(result) > 0 && !reflect.DeepEqual(result, test.expected)) {
+			t.Errorf("list%v: expected %v, got %v", test.items, test.expected, result)
+		}
+	}
+}
+
+func TestMakeSlice(t *testing.T) {
+	tests := []struct {
+		items    []interface{}
+		expected interface{}
+		isError  bool
+	}{
+		{[]interface{}{"a", "b", "c"}, []interface{}{"a", "b", "c"}, false},
+		{[]interface{}{"a"}, []interface{}{"a"}, false},
+		{[]interface{}{"abcdef", 1, 3}, "bc", false},
+		{[]interface{}{"abcdef", 2}, "cdef", false},
+		{[]interface{}{[]int{1, 2, 3, 4}, 1, 2, 3}, []int{2}, false},
+		{[]interface{}{"abcdef", "x"}, []interface{}{"abcdef", "x"}, false},
+		{[]interface{}{1, 2, 3}, []interface{}{1, 2, 3}, false},
+		{[]interface{}{"abc", 2, 5}, nil, true},
+		{[]interface{}{"abc", 2, 1}, nil, true},
+		{[]interface{}{"abc", 0, 1, 2}, nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := makeSlice(test.items...)
+		if (err != nil) != test.isError {
+			t.Errorf("slice%v: expected error %v, got %v", test.items, test.isError, err)
+			continue
+		}
+
+		if !test.isError && !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("slice%v: expected %v, got %v", test.items, test.expected, result)
+		}
+	}
+}
+
+func TestConvertNumber(t *testing.T) {
+	tests := []struct {
+		value         interface{}
+		expectedInt   int
+		expectedFloat float64
+		isError       bool
+	}{
+		{3, 3, 3, false},
+		{int64(7), 7, 7, false},
+		{uint8(2), 2, 2, false},
+		{2.9, 2, 2.9, false},
+		{-2.9, -2, -2.9, false},
+		{"42", 42, 42, false},
+		{" 1.5 ", 1, 1.5, false},
+		{"abc", 0, 0, true},
+		{true, 0, 0, true},
+		{nil, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		i, err := convertInt(test.value)
+		if (err != nil) != test.isError {
+			t.Errorf("int %v: expected error %v, got %v", test.value, test.isError, err)
+		} else if i != test.expectedInt {
+			t.Errorf("int %v: expected %d, got %d", test.value, test.expectedInt, i)
+		}
+
+		f, err := convertFloat(test.value)
+		if (err != nil) != test.isError {
+			t.Errorf("float %v: expected error %v, got %v", test.value, test.isError, err)
+		} else if f != test.expectedFloat {
+			t.Errorf("float %v: expected %v, got %v", test.value, test.expectedFloat, f)
+		}
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		def      interface{}
+		value    []interface{}
+		expected interface{}
+	}{
+		{"default", nil, "default"},
+		{"default", []interface{}{""}, "default"},
+		{"default", []interface{}{nil}, "default"},
+		{"default", []interface{}{0}, "default"},
+		{"default", []interface{}{false}, "default"},
+		{"default", []interface{}{[]string{}}, "default"},
+		{"default", []interface{}{"value"}, "value"},
+		{10, []interface{}{3}, 3},
+		{"default", []interface{}{true}, true},
+	}
+
+	for _, test := range tests {
+		if result := defaultValue(test.def, test.value...); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("default %v %v: expected %v, got %v", test.def, test.value, test.expected, result)
+		}
+	}
+}
+
+func TestJsonify(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected template.JS
+		isError  bool
+	}{
+		{map[string]interface{}{"b": "x", "a": 1}, `{"a":1,"b":"x"}`, false},
+		{[]interface{}{1, "x", true}, `[1,"x",true]`, false},
+		{"<script>", `"\u003cscript\u003e"`, false},
+		{nil, `null`, false},
+		{make(chan int), "", true},
+	}
+
+	for _, test := range tests {
+		result, err := jsonify(test.value)
+		if (err != nil) != test.isError {
+			t.Errorf("jsonify(%v): expected error %v, got %v", test.value, test.isError, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("jsonify(%v): expected %s, got %s", test.value, test.expected, result)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(int, int) (int, error)
+		a, b     int
+		expected int
+		isError  bool
+	}{
+		{"add", func(a, b int) (int, error) { return mathAdd(a, b), nil }, 2, 3, 5, false},
+		{"sub", func(a, b int) (int, error) { return mathSub(a, b), nil }, 2, 3, -1, false},
+		{"mul", func(a, b int) (int, error) { return mathMul(a, b), nil }, -2, 3, -6, false},
+		{"div", mathDiv, 7, 2, 3, false},
+		{"div", mathDiv, -7, 2, -3, false},
+		{"div", mathDiv, 7, 0, 0, true},
+		{"mod", mathMod, 7, 3, 1, false},
+		{"mod", mathMod, 7, 0, 0, true},
+		{"min", func(a, b int) (int, error) { return mathMin(a, b), nil }, 4, -1, -1, false},
+		{"max", func(a, b int) (int, error) { return mathMax(a, b), nil }, 4, -1, 4, false},
+	}
+
+	for _, test := range tests {
+		result, err := test.fn(test.a, test.b)
+		if (err != nil) != test.isError {
+			t.Errorf("%s %d %d: expected error %v, got %v", test.name, test.a, test.b, test.isError, err)
+			continue
+		}
+
+		if !test.isError && result != test.expected {
+			t.Errorf("%s %d %d: expected %d, got %d", test.name, test.a, test.b, test.expected, result)
+		}
+	}
+
+	if result := mathMin(5, 3, 8, 1); result != 1 {
+		t.Errorf("min 5 3 8 1: expected 1, got %d", result)
+	}
+
+	if result := mathMax(5, 3, 8, 1); result != 8 {
+		t.Errorf("max 5 3 8 1: expected 8, got %d", result)
+	}
+}
+
+func TestFunctionsInTemplate(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`{{add 1 2}} {{sub 5 3}} {{mul 2 4}} {{div 9 2}} {{mod 9 2}}`, "3 2 8 4 1"},
+		{`{{if lt (add 1 2) 4}}yes{{else}}no{{end}}`, "yes"},
+		{`{{if ge (max 1 5 3) 5}}yes{{else}}no{{end}}`, "yes"},
+		{`{{if eq (min 4 2 6) 2}}yes{{else}}no{{end}}`, "yes"},
+		{`{{if ne (mod 7 3) 1}}yes{{else}}no{{end}}`, "no"},
+		{`{{range seq 3}}{{.}}{{end}}`, "123"},
+		{`{{range list "a" "b"}}{{.}}{{end}}`, "ab"},
+		{`{{slice "abcdef" 1 3}}`, "bc"},
+		{`{{range slice "a" "b"}}{{.}}{{end}}`, "ab"},
+		{`{{range seq 0}}{{.}}{{end}}`, ""},
+		{`{{add (int "2") (int 1.5)}} {{float "0.5"}}`, "3 0.5"},
+		{`{{index (dict "a" 1) "a"}}`, "1"},
+		{`{{"" | default "none"}}`, "none"},
+		{`{{"hello world" | title}}`, "Hello World"},
+		{`{{"Hello, World" | slugify}}`, "hello-world"},
+		{`{{truncate 10 "Hello world, foo"}}`, "Hello…"},
+		{`{{replace "o" "0" "foo"}}`, "f00"},
+		{`{{join "," (split " " "a b c")}}`, "a,b,c"},
+	}
+
+	for _, test := range tests {
+		tpl, err := template.New("").Funcs((Worker{}).funcMap()).Parse(test.text)
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", test.text, err)
+			continue
+		}
+
+		buf := bytes.NewBuffer(nil)
+		if err = tpl.Execute(buf, nil); err != nil {
+			t.Errorf("%s: failed to execute: %v", test.text, err)
+			continue
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.text, test.expected, buf.String())
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	// Create template with the partials
+	tpl := template.New("").Funcs((Worker{}).funcMap())
+	template.Must(tpl.New("card.html").Parse(`[{{.title}}|{{.url}}]`))
+	template.Must(tpl.New("nav/item.html").Parse(`({{.}})`))
+	tpl.Funcs(template.FuncMap{"include": includeFunc(tpl)})
+
+	tests := []struct {
+		text     string
+		expected string
+		isError  bool
+	}{
+		{`{{include "card.html" "title" "A" "url" "/a"}}`, "[A|/a]", false},
+		{`{{include "card" "title" "B" "url" "/b"}}`, "[B|/b]", false},
+		{`{{include "nav/item" "single"}}`, "(single)", false},
+		{`{{include "card.html"}}`, "[|]", false},
+		{`{{include "missing"}}`, "", true},
+		{`{{include "card.html" "title" "A" "url"}}`, "", true},
+		{`{{include "card.html" 1 "A"}}`, "", true},
+	}
+
+	for _, test := range tests {
+		page, err := tpl.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		page, err = page.New("page").Parse(test.text)
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", test.text, err)
+			continue
+		}
+
+		page.Funcs(template.FuncMap{"include": includeFunc(page)})
+		buf := bytes.NewBuffer(nil)
+		err = page.Execute(buf, nil)
+		if (err != nil) != test.isError {
+			t.Errorf("%s: expected error %v, got %v", test.text, test.isError, err)
+			continue
+		}
+
+		if !test.isError && buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.text, test.expected, buf.String())
+		}
+	}
+
+	// Without template, partials are not available
+	if _, err := includeFunc(nil)("card.html"); err == nil {
+		t.Error("include without template: expected error, got nil")
+	}
+}
+
+func TestCloneTemplate(t *testing.T) {
+	// Create cached template whose partial is included by the main template
+	cached := template.New("page").Funcs((Worker{}).funcMap())
+	template.Must(cached.Parse(`[{{include "item" .}}]`))
+	template.Must(cached.New("item").Parse(`{{.}}`))
+
+	// Every render gets its own clone, so the cached one is never executed
+	for _, data := range []string{"a", "b"} {
+		clone, err := (Worker{}).cloneTemplate(cached)
+		if err != nil {
+			t.Fatalf("failed to clone template: %v", err)
+		}
+
+		buf := bytes.NewBuffer(nil)
+		if err = clone.Execute(buf, data); err != nil {
+			t.Fatalf("failed to execute clone: %v", err)
+		}
+
+		if expected := "[" + data + "]"; buf.String() != expected {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	}
+
+	// The include in cached template is still unbound
+	if err := cached.Execute(bytes.NewBuffer(nil), "c"); err == nil {
+		t.Error("cached template: expected error from unbound include, got nil")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	// Create site with a file inside, and a secret file outside of it
+	baseDir := t.TempDir()
+	rootDir := fp.Join(baseDir, "site")
+	secretPath := fp.Join(baseDir, "secret.txt")
+
+	writeFile := func(path, content string) {
+		os.MkdirAll(fp.Dir(path), os.ModePerm)
+		if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(fp.Join(rootDir, "data", "links.txt"), "links")
+	writeFile(secretPath, "secret")
+
+	hasSymlink := os.Symlink(secretPath, fp.Join(rootDir, "data", "link.txt")) == nil
+
+	tests := []struct {
+		path     string
+		expected string
+		isError  bool
+	}{
+		{"data/links.txt", "links", false},
+		{"./data/links.txt", "links", false},
+		{"/data/links.txt", "links", false},
+		{"data/../data/links.txt", "links", false},
+		{"data/missing.txt", "", true},
+		{"../secret.txt", "", true},
+		{"data/../../secret.txt", "", true},
+		{"../../../../../../etc/passwd", "", true},
+		{secretPath, "", true},
+		{"/etc/passwd", "", true},
+	}
+
+	if hasSymlink {
+		tests = append(tests, struct {
+			path     string
+			expected string
+			isError  bool
+		}{"data/link.txt", "", true})
+	}
+
+	wk := Worker{RootDir: rootDir}
+	for _, test := range tests {
+		result, err := wk.readFile(test.path)
+		if (err != nil) != test.isError {
+			t.Errorf("readFile(%q): expected error %v, got %v", test.path, test.isError, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("readFile(%q): expected %q, got %q", test.path, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	highlighting "github.com/yuin/goldmark-highlighting"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// defaultMarkdownExtensions is markdown extensions that used when there are
+// no extensions specified in metadata.
+var defaultMarkdownExtensions = []string{
+	"gfm",
+	"definitionlist",
+	"footnote",
+	"emoji",
+	"highlighting",
+	"mathjax",
+	"admonition",
+}
+
+// htmlOption is option for goldmark's HTML renderer.
+type htmlOption interface {
+	renderer.Option
+	html.Option
+}
+
+// markdownResult is the result of converting markdown into HTML.
+type markdownResult struct {
+	HTML        []byte
+	TOC         []model.TOCItem
+	InlineTOC   bool
+	RemovedHTML []string
+}
+
+func convertMarkdownToHTML(bt []byte, cfg model.MarkdownConfig, hooks *hookRenderer, shortcodes *shortcodeResolver, isSummary bool) (result markdownResult, err error) {
+	// Prepare highlighting options
+	chromaOptions := []chromahtml.Option{
+		chromahtml.WithClasses(cfg.HighlightStyle == ""),
+	}
+
+	if boolValue(cfg.LineNumbers, false) {
+		chromaOptions = append(chromaOptions, chromahtml.WithLineNumbers(true))
+	}
+
+	highlightOptions := []highlighting.Option{
+		highlighting.WithFormatOptions(chromaOptions...),
+	}
+
+	if cfg.HighlightStyle != "" {
+		highlightOptions = append(highlightOptions, highlighting.WithStyle(cfg.HighlightStyle))
+	}
+
+	// Prepare extensions
+	extensionNames := cfg.Extensions
+	if extensionNames == nil {
+		extensionNames = defaultMarkdownExtensions
+	}
+
+	useHighlighting := false
+	extensions := []goldmark.Extender{}
+	for _, name := range extensionNames {
+		switch strings.ToLower(name) {
+		case "gfm":
+			extensions = append(extensions, extension.GFM)
+		case "table":
+			extensions = append(extensions, extension.Table)
+		case "strikethrough":
+			extensions = append(extensions, extension.Strikethrough)
+		case "linkify":
+			extensions = append(extensions, extension.Linkify)
+		case "tasklist":
+			extensions = append(extensions, extension.TaskList)
+		case "definitionlist":
+			extensions = append(extensions, extension.DefinitionList)
+		case "footnote":
+			extensions = append(extensions, extension.Footnote)
+		case "emoji":
+			extensions = append(extensions, emoji.Emoji)
+		case "mathjax":
+			extensions = append(extensions, mathjax.MathJax)
+		case "admonition":
+			admonitionTypes := cfg.Admonitions
+			if admonitionTypes == nil {
+				admonitionTypes = defaultAdmonitionTypes
+			}
+			extensions = append(extensions, &admonitionExtension{types: admonitionTypes})
+		case "highlighting":
+			useHighlighting = true
+			extensions = append(extensions, highlighting.NewHighlighting(highlightOptions...))
+		default:
+			err = fmt.Errorf("unknown markdown extension %s", name)
+			return
+		}
+	}
+
+	if boolValue(cfg.Typographer, false) {
+		extensions = append(extensions, extension.Typographer)
+	}
+
+	// Prepare parser options
+	parserOptions := []parser.Option{}
+	parserContextOptions := []parser.ContextOption{}
+
+	// Summary is shown outside of its page, e.g. in list of pages, so its
+	// headings don't have ID that might clash with the other summaries
+	headingID := strings.ToLower(cfg.HeadingID)
+	if isSummary {
+		headingID = "none"
+	}
+
+	switch headingID {
+	case "", "default":
+		parserOptions = append(parserOptions, parser.WithAutoHeadingID())
+	case "github":
+		parserOptions = append(parserOptions, parser.WithAutoHeadingID())
+		parserContextOptions = append(parserContextOptions, parser.WithIDs(newGithubIDs()))
+	case "none":
+	default:
+		err = fmt.Errorf("unknown heading ID style %s", cfg.HeadingID)
+		return
+	}
+
+	if boolValue(cfg.Attributes, false) {
+		parserOptions = append(parserOptions, parser.WithAttribute())
+	}
+
+	// Headings are collected into table of contents after document parsed
+	toc := newTOCCollector(cfg, shortcodes, isSummary)
+	parserOptions = append(parserOptions,
+		parser.WithASTTransformers(util.Prioritized(toc, 1000)))
+
+	// Prepare renderer options
+	htmlOptions := []htmlOption{}
+	if boolValue(cfg.HardWraps, true) {
+		htmlOptions = append(htmlOptions, html.WithHardWraps())
+	}
+
+	if boolValue(cfg.XHTML, true) {
+		htmlOptions = append(htmlOptions, html.WithXHTML())
+	}
+
+	rendererOptions := []renderer.Option{}
+	for _, opt := range htmlOptions {
+		rendererOptions = append(rendererOptions, opt)
+	}
+
+	// Raw HTML is rendered following the policy in config
+	rawHTML, err := newRawHTMLRenderer(cfg)
+	if err != nil {
+		return
+	}
+
+	rendererOptions = append(rendererOptions,
+		renderer.WithNodeRenderers(util.Prioritized(rawHTML, 100)),
+		renderer.WithNodeRenderers(util.Prioritized(toc, 500)))
+
+	// If render hooks exist, prioritize it over the default renderers.
+	// Code block without render hook will be rendered by highlighter, or by
+	// the default renderer if highlighting is disabled.
+	if hooks != nil {
+		funcs := rendererFuncs{}
+		if useHighlighting {
+			highlighting.NewHTMLRenderer(highlightOptions...).RegisterFuncs(funcs)
+		} else {
+			defaultOptions := []html.Option{}
+			for _, opt := range htmlOptions {
+				defaultOptions = append(defaultOptions, opt)
+			}
+			html.NewRenderer(defaultOptions...).RegisterFuncs(funcs)
+		}
+
+		hooks.codeBlockRenderer = funcs[ast.KindFencedCodeBlock]
+		rendererOptions = append(rendererOptions,
+			renderer.WithNodeRenderers(util.Prioritized(hooks, 100)))
+	}
+
+	// Create markdown converter
+	md := goldmark.New(
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithRendererOptions(rendererOptions...),
+	)
+
+	if hooks != nil {
+		hooks.renderer = md.Renderer()
+	}
+
+	buf := bytes.NewBuffer(nil)
+	ctx := parser.NewContext(parserContextOptions...)
+	err = md.Convert(bt, buf, parser.WithContext(ctx))
+	if err != nil {
+		return
+	}
+
+	result.HTML = buf.Bytes()
+	result.TOC = toc.items
+	result.InlineTOC = toc.hasMarker
+	result.RemovedHTML = rawHTML.Removed()
+	return
+}
+
+// inheritMarkdownConfig fills the empty fields in markdown config using
+// the value from parent's config.
+func inheritMarkdownConfig(cfg *model.MarkdownConfig, parent model.MarkdownConfig) {
+	if cfg.Extensions == nil {
+		cfg.Extensions = parent.Extensions
+	}
+
+	if cfg.HardWraps == nil {
+		cfg.HardWraps = parent.HardWraps
+	}
+
+	if cfg.XHTML == nil {
+		cfg.XHTML = parent.XHTML
+	}
+
+	if cfg.Typographer == nil {
+		cfg.Typographer = parent.Typographer
+	}
+
+	if cfg.Attributes == nil {
+		cfg.Attributes = parent.Attributes
+	}
+
+	if cfg.HeadingID == "" {
+		cfg.HeadingID = parent.HeadingID
+	}
+
+	if cfg.HighlightStyle == "" {
+		cfg.HighlightStyle = parent.HighlightStyle
+	}
+
+	if cfg.LineNumbers == nil {
+		cfg.LineNumbers = parent.LineNumbers
+	}
+
+	if cfg.Admonitions == nil {
+		cfg.Admonitions = parent.Admonitions
+	}
+
+	if cfg.TOCStartLevel == 0 {
+		cfg.TOCStartLevel = parent.TOCStartLevel
+	}
+
+	if cfg.TOCEndLevel == 0 {
+		cfg.TOCEndLevel = parent.TOCEndLevel
+	}
+
+	if cfg.RawHTML == "" {
+		cfg.RawHTML = parent.RawHTML
+	}
+
+	if cfg.AllowedElements == nil {
+		cfg.AllowedElements = parent.AllowedElements
+	}
+
+	if cfg.AllowedAttributes == nil {
+		cfg.AllowedAttributes = parent.AllowedAttributes
+	}
+}
+
+func boolValue(b *bool, fallback bool) bool {
+	if b == nil {
+		return fallback
+	}
+	return *b
+}
+
+// githubIDs generates heading IDs in GitHub style, i.e. lower cased,
+// punctuations removed and spaces replaced by dash. Unlike the default
+// goldmark IDs, non ASCII letters are kept.
+type githubIDs struct {
+	values map[string]struct{}
+}
+
+func newGithubIDs() *githubIDs {
+	return &githubIDs{values: make(map[string]struct{})}
+}
+
+// Generate implements parser.IDs.
+func (s *githubIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(string(value))) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r):
+			sb.WriteRune('-')
+		}
+	}
+
+	id := sb.String()
+	if id == "" {
+		id = "heading"
+	}
+
+	result := id
+	for i := 1; ; i++ {
+		if _, exist := s.values[result]; !exist {
+			break
+		}
+		result = fmt.Sprintf("%s-%d", id, i)
+	}
+
+	s.values[result] = struct{}{}
+	return []byte(result)
+}
+
+// Put implements parser.IDs.
+func (s *githubIDs) Put(value []byte) {
+	s.values[string(value)] = struct{}{}
+}
@@ -0,0 +1,491 @@
+package build
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	fp "path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// getPage returns content in specified URL path, which could be a file or
+// a directory. If the content doesn't exist or it's unpublished, returns empty
+// ContentPath whose URLPath is empty.
+func (wk Worker) getPage(urlPath string) (model.ContentPath, error) {
+	urlPath = path.Clean("/" + urlPath)
+	filePath := fp.Join(wk.ContentDir, urlPath+".md")
+	dirPath := fp.Join(wk.ContentDir, urlPath)
+
+	var isDir bool
+	var mdPath string
+	switch {
+	case urlPath != "/" && fileutils.IsFile(filePath):
+		mdPath = filePath
+	case fileutils.IsDir(dirPath):
+		isDir = true
+		mdPath = fp.Join(dirPath, "_index.md")
+	default:
+		return model.ContentPath{}, nil
+	}
+
+	meta, content, err := wk.parsePath(mdPath)
+	if err != nil {
+		return model.ContentPath{}, err
+	}
+
+	if !wk.isPublished(meta) {
+		return model.ContentPath{}, nil
+	}
+
+	return wk.newContentPath(urlPath, isDir, meta, content), nil
+}
+
+// pages returns files inside directory in specified URL path, sorted by the
+// sort order of the directory.
+func (wk Worker) pages(dirURLPath string) ([]model.ContentPath, error) {
+	return wk.listPages(dirURLPath, false, true)
+}
+
+// pagesRecursive returns files inside directory in specified URL path and
+// all of its sub directories, sorted by the sort order of the directory.
+func (wk Worker) pagesRecursive(dirURLPath string) ([]model.ContentPath, error) {
+	return wk.listPages(dirURLPath, true, true)
+}
+
+// tagged returns files that use the specified tag or its descendants. If items
+// is not specified, it will look for files in the entire site.
+func (wk Worker) tagged(tag string, items ...[]model.ContentPath) ([]model.ContentPath, error) {
+	if len(items) == 0 {
+		allPages, err := wk.pagesRecursive("/")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, allPages)
+	}
+
+	// Tags of each item are already normalized, so its slug is taken from
+	// its URL instead of resolving the alias again
+	tagSlug := wk.tagSlug(tag)
+	result := []model.ContentPath{}
+	for _, list := range items {
+		for _, item := range list {
+			for _, itemTag := range item.Tags {
+				if _, itemSlug := splitTagURL(itemTag.URLPath); tagIncludes(tagSlug, itemSlug) {
+					result = append(result, item)
+					break
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// listPages returns files inside directory in specified URL path. Unpublished
+// files like drafts are excluded, while hidden files and content of hidden sub
+// directories are always excluded.
+// Headless files are only included if withHeadless is true, which used by
+// template functions so headless content can be queried.
+func (wk Worker) listPages(dirURLPath string, recursive bool, withHeadless bool) ([]model.ContentPath, error) {
+	dirPath := fp.Join(wk.ContentDir, path.Clean("/"+dirURLPath))
+	if !fileutils.IsDir(dirPath) {
+		return nil, fmt.Errorf("%s is not a directory in site content", dirURLPath)
+	}
+
+	// Collect markdown files
+	filePaths := []string{}
+	if recursive {
+		err := fp.WalkDir(dirPath, func(fPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() && d.Name() == tagDescDir {
+				return fp.SkipDir
+			}
+
+			if d.IsDir() && fPath != dirPath && wk.isUnlistedDir(fPath, withHeadless) {
+				return fp.SkipDir
+			}
+
+			if !d.IsDir() && fp.Ext(fPath) == ".md" && d.Name() != "_index.md" {
+				filePaths = append(filePaths, fPath)
+			}
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		dirItems, err := os.ReadDir(dirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range dirItems {
+			if !item.IsDir() && fp.Ext(item.Name()) == ".md" && item.Name() != "_index.md" {
+				filePaths = append(filePaths, fp.Join(dirPath, item.Name()))
+			}
+		}
+	}
+
+	// Parse each file
+	files := []model.ContentPath{}
+	for _, filePath := range filePaths {
+		meta, content, err := wk.parsePath(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !wk.isPublished(meta) || meta.Hidden || (meta.Headless && !withHeadless) {
+			continue
+		}
+
+		files = append(files, wk.newContentPath(wk.urlPathOf(filePath), false, meta, content))
+	}
+
+	// Sort the same way as files in directory listing
+	indexMdPath := fp.Join(dirPath, "_index.md")
+	dirMeta, _, err := wk.parsePath(indexMdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	sortKey, sortOrder := dirSortOrder(dirMeta, false)
+	files, err = sortContentPaths(files, sortKey, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexMdPath, err)
+	}
+
+	return files, nil
+}
+
+// where filters items whose field matches the value. The field could be
+// nested using dot, e.g. `Params.series`. The operator is optional, and
+// could be `=`, `!=`, `<`, `<=`, `>`, `>=`, `in`, `not in` or `contains`.
+// If operator is omitted, `=` will be used.
+func where(items []model.ContentPath, field string, args ...interface{}) ([]model.ContentPath, error) {
+	var operator string
+	var value interface{}
+
+	switch len(args) {
+	case 1:
+		operator, value = "=", args[0]
+	case 2:
+		op, isString := args[0].(string)
+		if !isString {
+			return nil, fmt.Errorf("operator must be a string, got %T", args[0])
+		}
+		operator, value = op, args[1]
+	default:
+		return nil, fmt.Errorf("where needs a value, optionally preceded by operator")
+	}
+
+	result := []model.ContentPath{}
+	for _, item := range items {
+		fieldValue, exist := contentField(item, field)
+		if !exist {
+			continue
+		}
+
+		match, err := matchValue(fieldValue, operator, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// sortBy sorts items by the field in ascending order, or in descending
+// order if the order is `desc`. Items with the same value keep their order.
+func sortBy(items []model.ContentPath, field string, order ...string) ([]model.ContentPath, error) {
+	descending := false
+	if len(order) > 0 {
+		switch strings.ToLower(order[0]) {
+		case "asc":
+		case "desc":
+			descending = true
+		default:
+			return nil, fmt.Errorf("unknown sort order %s", order[0])
+		}
+	}
+
+	result := append([]model.ContentPath{}, items...)
+	sort.SliceStable(result, func(a, b int) bool {
+		valueA, _ := contentField(result[a], field)
+		valueB, _ := contentField(result[b], field)
+		cmp, ok := compareValues(valueA, valueB)
+		if !ok {
+			return false
+		}
+
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	return result, nil
+}
+
+// first returns the first n items.
+func first(n int, items []model.ContentPath) []model.ContentPath {
+	if n < 0 {
+		n = 0
+	}
+
+	if n > len(items) {
+		n = len(items)
+	}
+
+	return append([]model.ContentPath{}, items[:n]...)
+}
+
+// last returns the last n items.
+func last(n int, items []model.ContentPath) []model.ContentPath {
+	if n < 0 {
+		n = 0
+	}
+
+	if n > len(items) {
+		n = len(items)
+	}
+
+	return append([]model.ContentPath{}, items[len(items)-n:]...)
+}
+
+// groupByYear groups items by the year when it created, or when it updated
+// if its create time is empty. Groups are sorted from the newest year, and
+// items without time are grouped in the last group with empty key.
+func groupByYear(items []model.ContentPath) []model.PageGroup {
+	groups := []model.PageGroup{}
+	groupIndexes := make(map[string]int)
+
+	for _, item := range items {
+		itemTime := item.CreateTime
+		if itemTime.IsZero() {
+			itemTime = item.UpdateTime
+		}
+
+		key := ""
+		if !itemTime.IsZero() {
+			key = strconv.Itoa(itemTime.Year())
+		}
+
+		idx, exist := groupIndexes[key]
+		if !exist {
+			idx = len(groups)
+			groupIndexes[key] = idx
+			groups = append(groups, model.PageGroup{Key: key})
+		}
+
+		groups[idx].Pages = append(groups[idx].Pages, item)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		keyA, keyB := groups[a].Key, groups[b].Key
+		if keyA == "" || keyB == "" {
+			return keyA != "" && keyB == ""
+		}
+
+		yearA, _ := strconv.Atoi(keyA)
+		yearB, _ := strconv.Atoi(keyB)
+		return yearA > yearB
+	})
+
+	return groups
+}
+
+// contentField returns value of the field in ContentPath. The field could
+// be nested using dot, which could be a struct field or a map key.
+func contentField(item model.ContentPath, field string) (interface{}, bool) {
+	value := reflect.ValueOf(item)
+	for _, name := range strings.Split(field, ".") {
+		for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil, false
+			}
+			value = value.Elem()
+		}
+
+		switch value.Kind() {
+		case reflect.Struct:
+			value = value.FieldByName(name)
+		case reflect.Map:
+			if value.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			value = value.MapIndex(reflect.ValueOf(name))
+		default:
+			return nil, false
+		}
+
+		if !value.IsValid() {
+			return nil, false
+		}
+	}
+
+	return value.Interface(), true
+}
+
+// matchValue checks whether the field value matches the value using the operator.
+func matchValue(fieldValue interface{}, operator string, value interface{}) (bool, error) {
+	switch operator {
+	case "=", "==", "eq":
+		return valuesEqual(fieldValue, value), nil
+
+	case "!=", "ne":
+		return !valuesEqual(fieldValue, value), nil
+
+	case "<", "<=", ">", ">=", "lt", "le", "gt", "ge":
+		cmp, ok := compareValues(fieldValue, value)
+		if !ok {
+			return false, nil
+		}
+
+		switch operator {
+		case "<", "lt":
+			return cmp < 0, nil
+		case "<=", "le":
+			return cmp <= 0, nil
+		case ">", "gt":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+
+	case "in":
+		return containsValue(value, fieldValue), nil
+
+	case "not in":
+		return !containsValue(value, fieldValue), nil
+
+	case "contains":
+		return containsValue(fieldValue, value), nil
+
+	default:
+		return false, fmt.Errorf("unknown operator %s", operator)
+	}
+}
+
+// containsValue checks whether the list contains the value. List could be a
+// slice or a string. For list of tags, the value is compared to tag name.
+func containsValue(list interface{}, value interface{}) bool {
+	if str, isString := list.(string); isString {
+		substr, isString := value.(string)
+		return isString && strings.Contains(str, substr)
+	}
+
+	listValue := reflect.ValueOf(list)
+	if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+		return false
+	}
+
+	for i := 0; i < listValue.Len(); i++ {
+		item := listValue.Index(i).Interface()
+		if tag, isTag := item.(model.TagPath); isTag {
+			item = tag.Name
+		}
+
+		if valuesEqual(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// valuesEqual checks whether both values are equal. Numbers are compared by
+// its value regardless of its type.
+func valuesEqual(a, b interface{}) bool {
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp == 0
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues compares two values of the same kind, i.e. both numbers,
+// strings, booleans or times. Returns false if they can't be compared.
+func compareValues(a, b interface{}) (int, bool) {
+	if timeA, isTime := a.(time.Time); isTime {
+		timeB, isTime := b.(time.Time)
+		switch {
+		case !isTime:
+			return 0, false
+		case timeA.Before(timeB):
+			return -1, true
+		case timeA.After(timeB):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	if numA, isNumber := toFloat(a); isNumber {
+		numB, isNumber := toFloat(b)
+		switch {
+		case !isNumber:
+			return 0, false
+		case numA < numB:
+			return -1, true
+		case numA > numB:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	if strA, isString := a.(string); isString {
+		strB, isString := b.(string)
+		if !isString {
+			return 0, false
+		}
+		return strings.Compare(strA, strB), true
+	}
+
+	if boolA, isBool := a.(bool); isBool {
+		boolB, isBool := b.(bool)
+		switch {
+		case !isBool:
+			return 0, false
+		case boolA == boolB:
+			return 0, true
+		case !boolA:
+			return -1, true
+		default:
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+// toFloat converts number of any type into float64.
+func toFloat(v interface{}) (float64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,67 @@
+package build
+
+import (
+	"path"
+	"strconv"
+)
+
+// pagination is the position of a page within a paginated list. Start and
+// End are the range of items in the page, which used for slicing the list.
+type pagination struct {
+	CurrentPage int
+	MaxPage     int
+	Start       int
+	End         int
+}
+
+// paginate splits nItems into pages with the specified size, then returns the
+// page for the page number. If page size is not positive, all items are put
+// in a single page. Empty list still has one page, while page number outside
+// the range is moved to the nearest page.
+func paginate(nItems int, pageSize int, pageNumber int) pagination {
+	if pageSize <= 0 {
+		return pagination{CurrentPage: 1, MaxPage: 1, Start: 0, End: nItems}
+	}
+
+	maxPage := (nItems + pageSize - 1) / pageSize
+	if maxPage < 1 {
+		maxPage = 1
+	}
+
+	currentPage := pageNumber
+	switch {
+	case currentPage < 1:
+		currentPage = 1
+	case currentPage > maxPage:
+		currentPage = maxPage
+	}
+
+	start := (currentPage - 1) * pageSize
+	end := currentPage * pageSize
+	if end > nItems {
+		end = nItems
+	}
+
+	return pagination{
+		CurrentPage: currentPage,
+		MaxPage:     maxPage,
+		Start:       start,
+		End:         end,
+	}
+}
+
+// pageURLs returns URL path of every page in a paginated list, which made by
+// appending the page number to the base URL. If there is only one page, there
+// are no need to create URL for it.
+func pageURLs(baseURLPath string, maxPage int) []string {
+	if maxPage <= 1 {
+		return nil
+	}
+
+	urls := make([]string, 0, maxPage)
+	for i := 1; i <= maxPage; i++ {
+		urls = append(urls, path.Join(baseURLPath, strconv.Itoa(i)))
+	}
+
+	return urls
+}
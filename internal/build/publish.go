@@ -0,0 +1,129 @@
+package build
+
+import (
+	"io/fs"
+	"path"
+	fp "path/filepath"
+	"sort"
+	"time"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// Publish status of a content, returned by PublishStatus.
+const (
+	StatusPublished = ""
+	StatusScheduled = "scheduled"
+	StatusExpired   = "expired"
+)
+
+// publishStatus returns publish status of content with the metadata. Content
+// is scheduled if its publish time is still in the future, and expired if its
+// expiry time has passed.
+func publishStatus(meta model.Metadata) string {
+	now := time.Now()
+	switch {
+	case !meta.PublishTime.IsZero() && meta.PublishTime.After(now):
+		return StatusScheduled
+	case !meta.ExpiryTime.IsZero() && !meta.ExpiryTime.After(now):
+		return StatusExpired
+	default:
+		return StatusPublished
+	}
+}
+
+// isPublished checks whether content with the metadata should be built. Drafts,
+// scheduled and expired content are treated as unpublished, unless the worker
+// is configured to build them.
+func (wk *Worker) isPublished(meta model.Metadata) bool {
+	if meta.Draft && !wk.buildDraft {
+		return false
+	}
+
+	switch publishStatus(meta) {
+	case StatusScheduled:
+		return wk.buildFuture
+	case StatusExpired:
+		return wk.buildExpired
+	default:
+		return true
+	}
+}
+
+// PublishStatus returns publish status of the file or directory in specified
+// URL path. For the other pages like tag pages and archives, it will always
+// return StatusPublished.
+func (wk *Worker) PublishStatus(urlPath string) string {
+	urlPath = path.Clean("/" + urlPath)
+	filePath := fp.Join(wk.ContentDir, urlPath+".md")
+	dirPath := fp.Join(wk.ContentDir, urlPath)
+
+	var mdPath string
+	switch {
+	case urlPath != "/" && fileutils.IsFile(filePath):
+		mdPath = filePath
+	case fileutils.IsDir(dirPath):
+		mdPath = fp.Join(dirPath, "_index.md")
+	default:
+		return StatusPublished
+	}
+
+	meta, _, err := wk.parseMarkdown(mdPath)
+	if err != nil {
+		return StatusPublished
+	}
+
+	return publishStatus(meta)
+}
+
+// ScheduledContent returns all files and directories whose publish time is
+// still in the future, sorted from the earliest to be published. Drafts are
+// excluded since they are not going to be published on their own. Since the
+// content is not rendered, the summary and word count are empty.
+func (wk *Worker) ScheduledContent() ([]model.ContentPath, error) {
+	items := []model.ContentPath{}
+	err := fp.WalkDir(wk.ContentDir, func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip tag descriptions since it's not part of content
+		if d.IsDir() && d.Name() == tagDescDir {
+			return fp.SkipDir
+		}
+
+		// We look for markdown file
+		if d.IsDir() || fp.Ext(fPath) != ".md" {
+			return nil
+		}
+
+		// Publish time and draft are not inherited, so only the metadata
+		// of the file is needed, without rendering its content
+		meta, err := wk.rawMeta(fPath)
+		if err != nil {
+			return err
+		}
+
+		if meta.Draft || publishStatus(meta) != StatusScheduled {
+			return nil
+		}
+
+		if meta.Title == "" {
+			meta.Title = fallbackTitle(fPath)
+		}
+
+		isDir := fp.Base(fPath) == "_index.md"
+		items = append(items, wk.newContentPath(wk.urlPathOf(fPath), isDir, meta, renderedContent{}))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].PublishTime.Before(items[b].PublishTime)
+	})
+
+	return items, nil
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScheduledContent(t *testing.T) {
+	wk := newTestWorker(t, map[string]string{
+		"blog/_index.md":     "+++\nTitle = \"Blog\"\n+++\n",
+		"blog/later.md":      "+++\nTitle = \"Later\"\nPublishTime = 2999-02-01T00:00:00Z\n+++\n",
+		"blog/untitled.md":   "+++\nPublishTime = 2999-01-01T00:00:00Z\n+++\n",
+		"blog/draft.md":      "+++\nTitle = \"Draft\"\nDraft = true\nPublishTime = 2999-01-01T00:00:00Z\n+++\n",
+		"blog/published.md":  "+++\nTitle = \"Published\"\nPublishTime = 2000-01-01T00:00:00Z\n+++\n",
+		"news/_index.md":     "+++\nPublishTime = 2999-03-01T00:00:00Z\n+++\n",
+		"_tags/scheduled.md": "+++\nPublishTime = 2999-01-01T00:00:00Z\n+++\n",
+	})
+
+	items, err := wk.ScheduledContent()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result [][2]string
+	for _, item := range items {
+		result = append(result, [2]string{item.URLPath, item.Title})
+	}
+
+	expected := [][2]string{
+		{"/blog/untitled", "untitled"},
+		{"/blog/later", "Later"},
+		{"/news", "news"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
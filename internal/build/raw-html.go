@@ -0,0 +1,291 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html"
+)
+
+// Policies for raw HTML inside markdown content.
+const (
+	rawHTMLStrip     = "strip"
+	rawHTMLAllow     = "allow"
+	rawHTMLAllowlist = "allowlist"
+)
+
+// defaultAllowedElements is elements that allowed in allowlist policy when
+// there are no allowed elements specified in metadata.
+var defaultAllowedElements = []string{
+	"a", "abbr", "audio", "br", "cite", "del", "details", "div",
+	"figcaption", "figure", "ins", "kbd", "mark", "picture", "q", "small",
+	"source", "span", "sub", "summary", "sup", "track", "u", "video",
+}
+
+// defaultAllowedAttributes is attributes that allowed in allowlist policy when
+// there are no allowed attributes specified in metadata.
+var defaultAllowedAttributes = []string{
+	"alt", "autoplay", "class", "controls", "height", "href", "id", "kind",
+	"label", "loop", "media", "muted", "open", "playsinline", "poster",
+	"sizes", "src", "srclang", "srcset", "title", "type", "width",
+}
+
+// urlAttributes is attributes whose value is an URL, so its scheme must be
+// checked to prevent script injection.
+var urlAttributes = map[string]struct{}{
+	"action": {},
+	"cite":   {},
+	"href":   {},
+	"poster": {},
+	"src":    {},
+	"srcset": {},
+}
+
+// rawTextElements is elements whose content is not markdown nor HTML, so
+// when the element is removed, its content is removed as well.
+var rawTextElements = map[string]struct{}{
+	"iframe":   {},
+	"noembed":  {},
+	"noframes": {},
+	"noscript": {},
+	"script":   {},
+	"style":    {},
+	"textarea": {},
+	"title":    {},
+	"xmp":      {},
+}
+
+// rawHTMLRenderer is goldmark renderer that renders raw HTML in markdown
+// following the raw HTML policy. While rendering, it also records the raw
+// HTML elements and attributes that removed.
+type rawHTMLRenderer struct {
+	policy            string
+	allowedElements   map[string]struct{}
+	allowedAttributes map[string]struct{}
+	removed           map[string]struct{}
+}
+
+func newRawHTMLRenderer(cfg model.MarkdownConfig) (*rawHTMLRenderer, error) {
+	policy := strings.ToLower(cfg.RawHTML)
+	switch policy {
+	case "":
+		policy = rawHTMLStrip
+	case rawHTMLStrip, rawHTMLAllow, rawHTMLAllowlist:
+	default:
+		return nil, fmt.Errorf("unknown raw HTML policy %s", cfg.RawHTML)
+	}
+
+	allowedElements := cfg.AllowedElements
+	if allowedElements == nil {
+		allowedElements = defaultAllowedElements
+	}
+
+	allowedAttributes := cfg.AllowedAttributes
+	if allowedAttributes == nil {
+		allowedAttributes = defaultAllowedAttributes
+	}
+
+	r := &rawHTMLRenderer{
+		policy:            policy,
+		allowedElements:   make(map[string]struct{}),
+		allowedAttributes: make(map[string]struct{}),
+		removed:           make(map[string]struct{}),
+	}
+
+	// In strip policy nothing is allowed
+	if policy == rawHTMLStrip {
+		return r, nil
+	}
+
+	for _, element := range allowedElements {
+		r.allowedElements[strings.ToLower(element)] = struct{}{}
+	}
+
+	for _, attribute := range allowedAttributes {
+		r.allowedAttributes[strings.ToLower(attribute)] = struct{}{}
+	}
+
+	return r, nil
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *rawHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, r.renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, r.renderRawHTML)
+}
+
+// Removed returns list of raw HTML elements and attributes that removed.
+func (r *rawHTMLRenderer) Removed() []string {
+	removed := make([]string, 0, len(r.removed))
+	for item := range r.removed {
+		removed = append(removed, item)
+	}
+
+	sort.Strings(removed)
+	return removed
+}
+
+func (r *rawHTMLRenderer) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.HTMLBlock)
+	if entering {
+		content := bytes.NewBuffer(nil)
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			content.Write(line.Value(source))
+		}
+
+		r.write(w, content.Bytes(), true)
+	} else if n.HasClosure() {
+		closure := n.ClosureLine
+		r.write(w, closure.Value(source), true)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *rawHTMLRenderer) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+
+	n := node.(*ast.RawHTML)
+	content := bytes.NewBuffer(nil)
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		content.Write(segment.Value(source))
+	}
+
+	r.write(w, content.Bytes(), false)
+	return ast.WalkSkipChildren, nil
+}
+
+// write writes the raw HTML following the policy.
+func (r *rawHTMLRenderer) write(w util.BufWriter, content []byte, isBlock bool) {
+	switch r.policy {
+	case rawHTMLAllow:
+		gmhtml.DefaultWriter.SecureWrite(w, content)
+
+	case rawHTMLAllowlist:
+		w.Write(r.sanitize(content))
+
+	default:
+		// Sanitize it anyway to find out which elements removed
+		r.sanitize(content)
+		w.WriteString("<!-- raw HTML omitted -->")
+		if isBlock {
+			w.WriteString("\n")
+		}
+	}
+}
+
+// sanitize removes elements and attributes that not allowed from HTML content.
+func (r *rawHTMLRenderer) sanitize(content []byte) []byte {
+	buf := bytes.NewBuffer(nil)
+	skippedElement := ""
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				r.removed["invalid HTML"] = struct{}{}
+			}
+			break
+		}
+
+		// If we are inside removed raw text element, skip until it's closed
+		token := tokenizer.Token()
+		if skippedElement != "" {
+			if tokenType == html.EndTagToken && token.Data == skippedElement {
+				skippedElement = ""
+			}
+			continue
+		}
+
+		switch tokenType {
+		case html.TextToken:
+			buf.WriteString(html.EscapeString(token.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if _, allowed := r.allowedElements[token.Data]; !allowed {
+				r.removed["<"+token.Data+">"] = struct{}{}
+				if _, isRawText := rawTextElements[token.Data]; isRawText && tokenType == html.StartTagToken {
+					skippedElement = token.Data
+				}
+				continue
+			}
+
+			attributes := []html.Attribute{}
+			for _, attr := range token.Attr {
+				_, allowed := r.allowedAttributes[attr.Key]
+				if allowed && r.isSafeAttribute(attr) {
+					attributes = append(attributes, attr)
+				} else {
+					r.removed["<"+token.Data+" "+attr.Key+">"] = struct{}{}
+				}
+			}
+
+			token.Attr = attributes
+			buf.WriteString(token.String())
+
+		case html.EndTagToken:
+			if _, allowed := r.allowedElements[token.Data]; allowed {
+				buf.WriteString(token.String())
+			}
+
+		case html.CommentToken, html.DoctypeToken:
+			// Comment and doctype are simply omitted
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// isSafeAttribute checks whether attribute is safe. Event handler like
+// `onclick` is never safe even if it's allowed, while URL attribute must not
+// use script scheme like `javascript:`.
+func (r *rawHTMLRenderer) isSafeAttribute(attr html.Attribute) bool {
+	if strings.HasPrefix(attr.Key, "on") {
+		return false
+	}
+
+	if _, isURL := urlAttributes[attr.Key]; !isURL {
+		return true
+	}
+
+	// Srcset contains several URLs, each followed by its descriptor
+	urls := []string{attr.Val}
+	if attr.Key == "srcset" {
+		urls = nil
+		for _, candidate := range strings.Split(attr.Val, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				urls = append(urls, fields[0])
+			}
+		}
+	}
+
+	for _, url := range urls {
+		url = strings.ToLower(strings.TrimSpace(url))
+		schemeEnd := strings.IndexAny(url, ":/?#")
+		if schemeEnd < 0 || url[schemeEnd] != ':' {
+			continue
+		}
+
+		switch url[:schemeEnd] {
+		case "http", "https", "mailto", "tel":
+		default:
+			return false
+		}
+	}
+
+	return true
+}
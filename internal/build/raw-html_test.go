@@ -0,0 +1,81 @@
+package build
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		html     string
+		expected string
+	}{
+		// Allowed elements and attributes are kept
+		{`<span class="x">hi</span>`, `<span class="x">hi</span>`},
+		{`<a href="https://example.com" title="t">x</a>`, `<a href="https://example.com" title="t">x</a>`},
+		{`<a href="/docs/page#id">x</a>`, `<a href="/docs/page#id">x</a>`},
+		{`<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com">x</a>`},
+
+		// Script and data URLs are removed
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<video src="data:video/mp4;base64,AAAA"></video>`, `<video></video>`},
+		{`<video poster="vbscript:msgbox(1)"></video>`, `<video></video>`},
+		{`<picture><source srcset="a.png 1x, javascript:alert(1) 2x"></picture>`, `<picture><source></picture>`},
+
+		// Scheme is checked case insensitively and after entities decoded
+		{`<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="  javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="jav&#x61;script:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="&#106;avascript&colon;alert(1)">x</a>`, `<a>x</a>`},
+		{"<a href=\"java\tscript:alert(1)\">x</a>", `<a>x</a>`},
+
+		// Event handlers and unknown attributes are removed
+		{`<span onclick="alert(1)">x</span>`, `<span>x</span>`},
+		{`<span ONMOUSEOVER="alert(1)" id="a">x</span>`, `<span id="a">x</span>`},
+		{`<span style="color:red">x</span>`, `<span>x</span>`},
+
+		// Disallowed element is removed but its allowed children are kept,
+		// except for raw text element whose content is removed as well
+		{`<form action="/x"><span>hi</span></form>`, `<span>hi</span>`},
+		{`<table><tr><td><b>x</b></td></tr></table>`, `x`},
+		{`<script>alert(1)</script><span>ok</span>`, `<span>ok</span>`},
+		{`<style>body{}</style>text`, `text`},
+		{`<!-- comment --><span>x</span>`, `<span>x</span>`},
+		{`a < b & c`, `a &lt; b &amp; c`},
+	}
+
+	for _, test := range tests {
+		r, err := newRawHTMLRenderer(model.MarkdownConfig{RawHTML: rawHTMLAllowlist})
+		if err != nil {
+			t.Fatalf("failed to create renderer: %v", err)
+		}
+
+		if result := string(r.sanitize([]byte(test.html))); result != test.expected {
+			t.Errorf("sanitize(%q): expected %q, got %q", test.html, test.expected, result)
+		}
+	}
+}
+
+func TestSanitizeCustomAllowlist(t *testing.T) {
+	r, err := newRawHTMLRenderer(model.MarkdownConfig{
+		RawHTML:           rawHTMLAllowlist,
+		AllowedElements:   []string{"IMG"},
+		AllowedAttributes: []string{"src", "onerror"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	html := `<img src="a.png" onerror="alert(1)"><span>x</span>`
+	expected := `<img src="a.png">x`
+	if result := string(r.sanitize([]byte(html))); result != expected {
+		t.Errorf("sanitize(%q): expected %q, got %q", html, expected, result)
+	}
+
+	expectedRemoved := []string{"<img onerror>", "<span>"}
+	if removed := r.Removed(); !reflect.DeepEqual(removed, expectedRemoved) {
+		t.Errorf("removed: expected %v, got %v", expectedRemoved, removed)
+	}
+}
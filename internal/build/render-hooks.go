@@ -0,0 +1,253 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Names of render hook templates. Code block template is suffixed by its
+// language, e.g. `render-codeblock-mermaid.html`.
+const (
+	linkHookName        = "render-link.html"
+	imageHookName       = "render-image.html"
+	headingHookName     = "render-heading.html"
+	codeBlockHookPrefix = "render-codeblock-"
+)
+
+// renderHookTemplate creates template that contains all render hooks in
+// specified theme. Render hooks are HTML files in theme's root dir whose name
+// prefixed by `render-`. If the theme doesn't have any render hook, returns nil.
+func (wk *Worker) renderHookTemplate(themeName string) (*template.Template, error) {
+	// Check if template already cached
+	if tpl, cached := wk.hookCache[themeName]; cached && wk.cacheEnabled {
+		if tpl == nil {
+			return nil, nil
+		}
+		return wk.cloneTemplate(tpl)
+	}
+
+	// Load theme along with its ancestors
+	theme, err := LoadTheme(wk.RootDir, themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	dirItems, err := theme.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse every render hooks, along with the partials so they can be used
+	// inside render hook.
+	var tpl *template.Template
+	for _, item := range dirItems {
+		name := item.Name()
+		if item.IsDir() || path.Ext(name) != ".html" || !strings.HasPrefix(name, "render-") {
+			continue
+		}
+
+		if tpl == nil {
+			tpl = template.New("").Funcs(wk.funcMap())
+		}
+
+		err = parseThemeTemplate(tpl.New(name), theme, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tpl != nil {
+		err = parseThemeDir(tpl, theme, "partials")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Save to cache
+	if wk.cacheEnabled {
+		wk.hookCache[themeName] = tpl
+	}
+
+	if tpl == nil {
+		return nil, nil
+	}
+
+	return wk.cloneTemplate(tpl)
+}
+
+// hookRenderer is goldmark renderer that renders markdown elements using
+// render hook templates. Element whose render hook doesn't exist will be
+// rendered by the default renderer.
+type hookRenderer struct {
+	template *template.Template
+	page     model.PageInfo
+
+	// renderer is the markdown renderer that used to render children of
+	// the element, while codeBlockRenderer is the fallback for code block
+	// whose language doesn't have render hook.
+	renderer          renderer.Renderer
+	codeBlockRenderer renderer.NodeRendererFunc
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *hookRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	if r.template.Lookup(linkHookName) != nil {
+		reg.Register(ast.KindLink, r.renderLink)
+	}
+
+	if r.template.Lookup(imageHookName) != nil {
+		reg.Register(ast.KindImage, r.renderImage)
+	}
+
+	if r.template.Lookup(headingHookName) != nil {
+		reg.Register(ast.KindHeading, r.renderHeading)
+	}
+
+	for _, t := range r.template.Templates() {
+		if strings.HasPrefix(t.Name(), codeBlockHookPrefix) {
+			reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+			break
+		}
+	}
+}
+
+func (r *hookRenderer) renderLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Link)
+	text, err := r.renderChildren(source, n)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	return ast.WalkSkipChildren, r.execute(w, linkHookName, model.LinkHookData{
+		Page:        r.page,
+		Destination: string(n.Destination),
+		Title:       string(n.Title),
+		Text:        text,
+		PlainText:   string(n.Text(source)),
+		Attributes:  nodeAttributes(n),
+	})
+}
+
+func (r *hookRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Image)
+	return ast.WalkSkipChildren, r.execute(w, imageHookName, model.ImageHookData{
+		Page:        r.page,
+		Destination: string(n.Destination),
+		Title:       string(n.Title),
+		Text:        string(n.Text(source)),
+		Attributes:  nodeAttributes(n),
+	})
+}
+
+func (r *hookRenderer) renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Heading)
+	text, err := r.renderChildren(source, n)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	attributes := nodeAttributes(n)
+	return ast.WalkSkipChildren, r.execute(w, headingHookName, model.HeadingHookData{
+		Page:       r.page,
+		Level:      n.Level,
+		ID:         attributes["id"],
+		Text:       text,
+		PlainText:  string(n.Text(source)),
+		Attributes: attributes,
+	})
+}
+
+func (r *hookRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.FencedCodeBlock)
+	language := string(n.Language(source))
+	hookName := codeBlockHookPrefix + language + ".html"
+
+	if language == "" || r.template.Lookup(hookName) == nil {
+		return r.codeBlockRenderer(w, source, node, entering)
+	}
+
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	code := bytes.NewBuffer(nil)
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	return ast.WalkSkipChildren, r.execute(w, hookName, model.CodeBlockHookData{
+		Page:       r.page,
+		Language:   language,
+		Code:       code.String(),
+		Attributes: nodeAttributes(n),
+	})
+}
+
+// renderChildren renders children of the node into HTML.
+func (r *hookRenderer) renderChildren(source []byte, node ast.Node) (template.HTML, error) {
+	buf := bytes.NewBuffer(nil)
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if err := r.renderer.Render(buf, source, child); err != nil {
+			return "", err
+		}
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// execute executes the render hook template then writes the result.
+func (r *hookRenderer) execute(w util.BufWriter, hookName string, data interface{}) error {
+	buf := bytes.NewBuffer(nil)
+	err := r.template.ExecuteTemplate(buf, hookName, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// nodeAttributes returns attributes of markdown node as string map.
+func nodeAttributes(node ast.Node) map[string]string {
+	attributes := make(map[string]string)
+	for _, attr := range node.Attributes() {
+		switch value := attr.Value.(type) {
+		case []byte:
+			attributes[string(attr.Name)] = string(value)
+		default:
+			attributes[string(attr.Name)] = fmt.Sprint(value)
+		}
+	}
+
+	return attributes
+}
+
+// rendererFuncs captures node renderer functions of a node renderer.
+type rendererFuncs map[ast.NodeKind]renderer.NodeRendererFunc
+
+// Register implements renderer.NodeRendererFuncRegisterer.
+func (rf rendererFuncs) Register(kind ast.NodeKind, fn renderer.NodeRendererFunc) {
+	rf[kind] = fn
+}
@@ -0,0 +1,453 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// shortcodePlaceholder is the text that put in markdown content in place of
+// the rendered shortcode. It's replaced by the real output after markdown
+// converted into HTML, so the output is not processed as markdown nor removed
+// by raw HTML policy.
+const shortcodePlaceholder = shortcodePlaceholderPrefix + "%dEND"
+
+// shortcodePlaceholderPrefix is the start of every shortcode placeholder.
+const shortcodePlaceholderPrefix = "BOOMSHORTCODE"
+
+// shortcodeTemplate creates template that contains all shortcodes in specified
+// theme. Shortcodes are HTML files in `shortcodes` dir of the theme, named by
+// its path relative to that dir. The partials are loaded as well so they can
+// be used inside shortcode.
+func (wk *Worker) shortcodeTemplate(themeName string) (*template.Template, error) {
+	// Check if template already cached
+	if tpl, cached := wk.shortcodeCache[themeName]; cached && wk.cacheEnabled {
+		return wk.cloneTemplate(tpl)
+	}
+
+	// Load theme along with its ancestors
+	theme, err := LoadTheme(wk.RootDir, themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shortcodes are parsed last, so they are not replaced by partials
+	// with the same name
+	tpl := template.New("").Funcs(wk.funcMap())
+	err = parseThemeDir(tpl, theme, "partials")
+	if err != nil {
+		return nil, err
+	}
+
+	err = parseThemeDir(tpl, theme, "shortcodes")
+	if err != nil {
+		return nil, err
+	}
+
+	// Save to cache
+	if wk.cacheEnabled {
+		wk.shortcodeCache[themeName] = tpl
+	}
+
+	return wk.cloneTemplate(tpl)
+}
+
+// shortcodeTag is a single shortcode tag that found in markdown content.
+// Escaped tag like `{{</* name */>}}` is not rendered, instead it's written
+// as Literal.
+type shortcodeTag struct {
+	Start         int
+	End           int
+	Name          string
+	Params        map[string]string
+	Args          []string
+	IsClosing     bool
+	IsSelfClosing bool
+	Literal       string
+}
+
+// shortcodeResolver renders shortcodes in markdown content of a page.
+type shortcodeResolver struct {
+	template    *template.Template
+	themeName   string
+	page        model.PageInfo
+	cfg         model.MarkdownConfig
+	hooks       *hookRenderer
+	isSummary   bool
+	outputs     []string
+	removedHTML []string
+}
+
+// resolve renders every shortcodes in markdown content, then replaces them
+// with placeholder.
+func (r *shortcodeResolver) resolve(mdContent []byte) ([]byte, error) {
+	result, err := r.expand(string(mdContent), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(result), nil
+}
+
+// restore replaces the placeholders in content with the rendered shortcodes.
+// If isHTML is true, placeholder that wrapped in its own paragraph will be
+// replaced as a whole, since shortcode output is usually a block element.
+func (r *shortcodeResolver) restore(content []byte, isHTML bool) []byte {
+	if len(r.outputs) == 0 {
+		return content
+	}
+
+	var pairs []string
+	if isHTML {
+		for i, output := range r.outputs {
+			pairs = append(pairs, "<p>"+fmt.Sprintf(shortcodePlaceholder, i)+"</p>", output)
+		}
+	}
+
+	for i, output := range r.outputs {
+		pairs = append(pairs, fmt.Sprintf(shortcodePlaceholder, i), output)
+	}
+
+	return []byte(strings.NewReplacer(pairs...).Replace(string(content)))
+}
+
+// plainText replaces the placeholders in text with plain text of the rendered
+// shortcodes. It's used for text that taken before markdown converted, like
+// heading ID and table of contents.
+func (r *shortcodeResolver) plainText(text string) string {
+	if r == nil || len(r.outputs) == 0 || !strings.Contains(text, shortcodePlaceholderPrefix) {
+		return text
+	}
+
+	var pairs []string
+	for i, output := range r.outputs {
+		pairs = append(pairs, fmt.Sprintf(shortcodePlaceholder, i), plainText([]byte(output)))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// expand renders shortcodes in the source, including the ones that nested
+// inside another shortcode.
+func (r *shortcodeResolver) expand(src string, parent *model.ShortcodeData) (string, error) {
+	var sb strings.Builder
+	for {
+		tag, found, err := findShortcodeTag(src)
+		if err != nil {
+			return "", err
+		}
+
+		if !found {
+			sb.WriteString(src)
+			break
+		}
+
+		sb.WriteString(src[:tag.Start])
+		rest := src[tag.End:]
+
+		if tag.Literal != "" {
+			sb.WriteString(tag.Literal)
+			src = rest
+			continue
+		}
+
+		if tag.IsClosing {
+			return "", fmt.Errorf("closing shortcode %s doesn't have opening tag", tag.Name)
+		}
+
+		data := &model.ShortcodeData{
+			Page:   r.page,
+			Name:   tag.Name,
+			Params: tag.Params,
+			Args:   tag.Args,
+			Parent: parent,
+		}
+
+		// If the shortcode is closed, render its inner content as well
+		if !tag.IsSelfClosing {
+			innerEnd, closeEnd, err := findClosingShortcode(rest, tag.Name)
+			if err != nil {
+				return "", err
+			}
+
+			if innerEnd >= 0 {
+				inner, err := r.expand(rest[:innerEnd], data)
+				if err != nil {
+					return "", err
+				}
+
+				data.Inner = string(r.restore([]byte(inner), false))
+				data.InnerHTML, err = r.markdownify(inner)
+				if err != nil {
+					return "", err
+				}
+
+				rest = rest[closeEnd:]
+			}
+		}
+
+		output, err := r.execute(data)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(fmt.Sprintf(shortcodePlaceholder, len(r.outputs)))
+		r.outputs = append(r.outputs, output)
+		src = rest
+	}
+
+	return sb.String(), nil
+}
+
+// markdownify converts inner content of shortcode into HTML.
+func (r *shortcodeResolver) markdownify(inner string) (template.HTML, error) {
+	result, err := convertMarkdownToHTML([]byte(inner), r.cfg, r.hooks, r, r.isSummary)
+	if err != nil {
+		return "", err
+	}
+
+	r.removedHTML = append(r.removedHTML, result.RemovedHTML...)
+	return template.HTML(r.restore(result.HTML, true)), nil
+}
+
+// execute executes the shortcode template then returns its output.
+func (r *shortcodeResolver) execute(data *model.ShortcodeData) (string, error) {
+	templateName := data.Name + ".html"
+	if r.template == nil || r.template.Lookup(templateName) == nil {
+		return "", fmt.Errorf("shortcode %s doesn't exist in theme %s", data.Name, r.themeName)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := r.template.ExecuteTemplate(buf, templateName, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Removed returns list of raw HTML that removed from inner content of shortcodes.
+func (r *shortcodeResolver) Removed() []string {
+	return r.removedHTML
+}
+
+// findClosingShortcode looks for closing tag of shortcode with specified name.
+// It returns the start and end position of the closing tag, or -1 if the
+// closing tag is not found.
+func findClosingShortcode(src string, name string) (start int, end int, err error) {
+	depth := 0
+	offset := 0
+	for {
+		tag, found, err := findShortcodeTag(src[offset:])
+		if err != nil {
+			return -1, -1, err
+		}
+
+		if !found {
+			return -1, -1, nil
+		}
+
+		tagStart, tagEnd := offset+tag.Start, offset+tag.End
+		offset = tagEnd
+
+		if tag.Literal != "" || tag.Name != name {
+			continue
+		}
+
+		switch {
+		case tag.IsClosing && depth == 0:
+			return tagStart, tagEnd, nil
+		case tag.IsClosing:
+			depth--
+		case !tag.IsSelfClosing:
+			depth++
+		}
+	}
+}
+
+// findShortcodeTag looks for the first shortcode tag in the source.
+func findShortcodeTag(src string) (tag shortcodeTag, found bool, err error) {
+	start := strings.Index(src, "{{<")
+	if start < 0 {
+		return
+	}
+
+	found = true
+	tag.Start = start
+
+	// Escaped tag is written as is, without the comment marks
+	if strings.HasPrefix(src[start+3:], "/*") {
+		end := strings.Index(src[start:], "*/>}}")
+		if end < 0 {
+			err = errors.New("escaped shortcode is not closed")
+			return
+		}
+
+		end += start
+		tag.Literal = "{{<" + src[start+5:end] + ">}}"
+		tag.End = end + 5
+		return
+	}
+
+	// Read the name, which might be prefixed by slash for closing tag
+	pos := skipSpaces(src, start+3)
+	if pos < len(src) && src[pos] == '/' {
+		tag.IsClosing = true
+		pos = skipSpaces(src, pos+1)
+	}
+
+	nameStart := pos
+	for pos < len(src) && isShortcodeNameChar(src[pos]) && !strings.HasPrefix(src[pos:], "/>}}") {
+		pos++
+	}
+
+	tag.Name = strings.Trim(src[nameStart:pos], "/")
+	if tag.Name == "" {
+		err = errors.New("shortcode doesn't have name")
+		return
+	}
+
+	// Read the arguments until the end of tag
+	tag.Params = make(map[string]string)
+	for {
+		pos = skipSpaces(src, pos)
+		rest := src[pos:]
+
+		switch {
+		case strings.HasPrefix(rest, ">}}"):
+			tag.End = pos + 3
+			return
+		case strings.HasPrefix(rest, "/>}}"):
+			tag.IsSelfClosing = true
+			tag.End = pos + 4
+			return
+		case rest == "":
+			err = fmt.Errorf("shortcode %s is not closed", tag.Name)
+			return
+		case tag.IsClosing:
+			err = fmt.Errorf("closing shortcode %s can't have arguments", tag.Name)
+			return
+		}
+
+		var key, value string
+		key, value, pos, err = readShortcodeArg(src, pos)
+		if err != nil {
+			err = fmt.Errorf("shortcode %s: %w", tag.Name, err)
+			return
+		}
+
+		if key != "" {
+			tag.Params[key] = value
+		} else {
+			tag.Args = append(tag.Args, value)
+		}
+	}
+}
+
+// readShortcodeArg reads a single shortcode argument, which might be named
+// like `key=value` or `key="value"`, or positional like `value` or `"value"`.
+// It returns the key, value and position after the argument.
+func readShortcodeArg(src string, pos int) (key string, value string, next int, err error) {
+	if src[pos] == '"' {
+		value, next, err = readQuotedValue(src, pos)
+		return
+	}
+
+	// Read bare value, which will be used as key if it's followed by equal sign
+	start := pos
+	for pos < len(src) && !isShortcodeArgEnd(src, pos) && src[pos] != '=' {
+		pos++
+	}
+
+	if pos >= len(src) || src[pos] != '=' {
+		return "", src[start:pos], pos, nil
+	}
+
+	key = src[start:pos]
+	if key == "" {
+		return "", "", pos, errors.New("argument doesn't have name")
+	}
+
+	// Read value of the named argument
+	pos++
+	if pos < len(src) && src[pos] == '"' {
+		value, next, err = readQuotedValue(src, pos)
+		return
+	}
+
+	start = pos
+	for pos < len(src) && !isShortcodeArgEnd(src, pos) {
+		pos++
+	}
+
+	return key, src[start:pos], pos, nil
+}
+
+// readQuotedValue reads string that wrapped in double quotes, which starts
+// in specified position. Backslash can be used to escape the quote.
+func readQuotedValue(src string, pos int) (value string, next int, err error) {
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted := src[pos : i+1]
+			if value, err = strconv.Unquote(quoted); err != nil {
+				value, err = quoted[1:len(quoted)-1], nil
+			}
+			return value, i + 1, nil
+		}
+	}
+
+	return "", len(src), errors.New("quoted argument is not closed")
+}
+
+// isShortcodeArgEnd checks whether character in specified position ends a bare argument.
+func isShortcodeArgEnd(src string, pos int) bool {
+	rest := src[pos:]
+	return isSpace(src[pos]) ||
+		strings.HasPrefix(rest, ">}}") ||
+		strings.HasPrefix(rest, "/>}}")
+}
+
+func isShortcodeNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '/'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipSpaces(src string, pos int) int {
+	for pos < len(src) && isSpace(src[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// mergeRemovedHTML merges lists of removed raw HTML, removing the duplicates.
+func mergeRemovedHTML(lists ...[]string) []string {
+	merged := make(map[string]struct{})
+	for _, list := range lists {
+		for _, item := range list {
+			merged[item] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(merged))
+	for item := range merged {
+		result = append(result, item)
+	}
+
+	sort.Strings(result)
+	return result
+}
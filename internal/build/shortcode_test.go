@@ -0,0 +1,136 @@
+package build
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+func TestFindShortcodeTag(t *testing.T) {
+	tests := []struct {
+		src      string
+		found    bool
+		expected shortcodeTag
+	}{
+		{"no shortcode here", false, shortcodeTag{}},
+		{"a {{< note >}} b", true, shortcodeTag{
+			Start: 2, End: 14, Name: "note", Params: map[string]string{},
+		}},
+		{"{{<note/>}}", true, shortcodeTag{
+			End: 11, Name: "note", Params: map[string]string{}, IsSelfClosing: true,
+		}},
+		{"{{< / note >}}", true, shortcodeTag{
+			End: 14, Name: "note", Params: map[string]string{}, IsClosing: true,
+		}},
+		{`{{< figure src="a b.png" alt=cat "pos 1" pos2 />}}`, true, shortcodeTag{
+			End:           50,
+			Name:          "figure",
+			Params:        map[string]string{"src": "a b.png", "alt": "cat"},
+			Args:          []string{"pos 1", "pos2"},
+			IsSelfClosing: true,
+		}},
+		{`{{< quote text="say \"hi\"" >}}`, true, shortcodeTag{
+			End: 31, Name: "quote", Params: map[string]string{"text": `say "hi"`},
+		}},
+		{`{{< quote ">}}" >}}`, true, shortcodeTag{
+			End: 19, Name: "quote", Params: map[string]string{}, Args: []string{">}}"},
+		}},
+		{"x {{</* note */>}} y", true, shortcodeTag{
+			Start: 2, End: 18, Literal: "{{< note >}}",
+		}},
+	}
+
+	for _, test := range tests {
+		tag, found, err := findShortcodeTag(test.src)
+		if err != nil {
+			t.Errorf("findShortcodeTag(%q): unexpected error: %v", test.src, err)
+			continue
+		}
+
+		if found != test.found {
+			t.Errorf("findShortcodeTag(%q): expected found %v, got %v", test.src, test.found, found)
+			continue
+		}
+
+		if found && !reflect.DeepEqual(tag, test.expected) {
+			t.Errorf("findShortcodeTag(%q): expected %+v, got %+v", test.src, test.expected, tag)
+		}
+	}
+}
+
+func TestFindShortcodeTagError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"{{< note", "shortcode note is not closed"},
+		{"{{< note key=value", "shortcode note is not closed"},
+		{`{{< note "unterminated >}}`, "quoted argument is not closed"},
+		{"{{</* note >}}", "escaped shortcode is not closed"},
+		{"{{< >}}", "shortcode doesn't have name"},
+		{"{{< /note key=value >}}", "closing shortcode note can't have arguments"},
+		{"{{< note =value >}}", "argument doesn't have name"},
+	}
+
+	for _, test := range tests {
+		_, _, err := findShortcodeTag(test.src)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("findShortcodeTag(%q): expected error %q, got %v", test.src, test.expected, err)
+		}
+	}
+}
+
+func TestFindClosingShortcode(t *testing.T) {
+	tests := []struct {
+		src   string
+		name  string
+		start int
+		end   int
+	}{
+		{"inner{{< /note >}}after", "note", 5, 18},
+		{"{{< note >}}x{{< /note >}}y{{< /note >}}", "note", 27, 40},
+		{"{{< note />}}x{{< /note >}}", "note", 14, 27},
+		{"{{< box >}}{{< /box >}}{{< /note >}}", "note", 23, 36},
+		{"{{</* /note */>}}{{< /note >}}", "note", 17, 30},
+		{"no closing tag", "note", -1, -1},
+		{"{{< note >}}{{< /note >}}", "note", -1, -1},
+	}
+
+	for _, test := range tests {
+		start, end, err := findClosingShortcode(test.src, test.name)
+		if err != nil {
+			t.Errorf("findClosingShortcode(%q): unexpected error: %v", test.src, err)
+			continue
+		}
+
+		if start != test.start || end != test.end {
+			t.Errorf("findClosingShortcode(%q): expected (%d, %d), got (%d, %d)",
+				test.src, test.start, test.end, start, end)
+		}
+	}
+}
+
+func TestShortcodeInHeading(t *testing.T) {
+	shortcodes := &shortcodeResolver{outputs: []string{"<code>v1.2</code>"}}
+	src := "## Release BOOMSHORTCODE0END\n\n## Other\n"
+
+	result, err := convertMarkdownToHTML([]byte(src), model.MarkdownConfig{}, nil, shortcodes, false)
+	if err != nil {
+		t.Fatalf("failed to convert markdown: %v", err)
+	}
+
+	expectedTOC := []model.TOCItem{
+		{Level: 2, ID: "release-v12", Text: "Release v1.2"},
+		{Level: 2, ID: "other", Text: "Other"},
+	}
+
+	if !reflect.DeepEqual(result.TOC, expectedTOC) {
+		t.Errorf("expected TOC %+v, got %+v", expectedTOC, result.TOC)
+	}
+
+	if html := string(result.HTML); !strings.Contains(html, `<h2 id="release-v12">`) {
+		t.Errorf("expected heading ID from shortcode text, got %s", html)
+	}
+}
@@ -0,0 +1,129 @@
+package build
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// summaryDivider is the marker in markdown content that separate summary
+// from the rest of content.
+const summaryDivider = "<!--more-->"
+
+// defaultSummaryLength is the count of words in automatic summary when
+// there are no summary length specified in metadata.
+const defaultSummaryLength = 70
+
+// wordsPerMinute is the average reading speed for estimating reading time.
+const wordsPerMinute = 200
+
+// blockElements is elements that separate words in its content from the
+// words outside of it.
+var blockElements = map[string]struct{}{
+	"aside": {}, "blockquote": {}, "br": {}, "dd": {}, "details": {},
+	"div": {}, "dt": {}, "figcaption": {}, "h1": {}, "h2": {}, "h3": {},
+	"h4": {}, "h5": {}, "h6": {}, "hr": {}, "li": {}, "ol": {}, "p": {},
+	"pre": {}, "section": {}, "summary": {}, "table": {}, "td": {}, "th": {},
+	"tr": {}, "ul": {},
+}
+
+// ignoredElements is elements whose content is not part of the text.
+var ignoredElements = map[string]struct{}{
+	"nav":    {},
+	"script": {},
+	"style":  {},
+}
+
+// splitSummary splits markdown content at the summary divider. It returns the
+// summary, and the content with divider removed. If the divider doesn't
+// exist, the summary will be nil.
+func splitSummary(mdContent []byte) (summary []byte, content []byte) {
+	idx := bytes.Index(mdContent, []byte(summaryDivider))
+	if idx < 0 {
+		return nil, mdContent
+	}
+
+	// Limit the capacity, so appending to summary won't overwrite the source
+	summary = mdContent[:idx:idx]
+	content = append([]byte{}, summary...)
+	content = append(content, mdContent[idx+len(summaryDivider):]...)
+	return summary, content
+}
+
+// plainText extracts text from HTML content. Words inside block elements are
+// separated by whitespace, while content of navigation and script is ignored.
+func plainText(htmlContent []byte) string {
+	var sb strings.Builder
+	ignoredDepth := 0
+	tokenizer := html.NewTokenizer(bytes.NewReader(htmlContent))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				sb.WriteString(" ")
+			}
+			break
+		}
+
+		token := tokenizer.Token()
+		_, isIgnored := ignoredElements[token.Data]
+		_, isBlock := blockElements[token.Data]
+
+		switch tokenType {
+		case html.TextToken:
+			if ignoredDepth == 0 {
+				sb.WriteString(token.Data)
+			}
+
+		case html.StartTagToken:
+			if isIgnored {
+				ignoredDepth++
+			}
+
+			if isBlock {
+				sb.WriteString(" ")
+			}
+
+		case html.EndTagToken:
+			if isIgnored && ignoredDepth > 0 {
+				ignoredDepth--
+			}
+
+			if isBlock {
+				sb.WriteString(" ")
+			}
+
+		case html.SelfClosingTagToken:
+			if isBlock {
+				sb.WriteString(" ")
+			}
+		}
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// summarizeText returns the first n words of the text as HTML. If the text
+// is truncated, it will be ended with ellipsis.
+func summarizeText(text string, n int) template.HTML {
+	words := strings.Fields(text)
+	if n > 0 && len(words) > n {
+		words = words[:n]
+		words[n-1] += "…"
+	}
+
+	return template.HTML(template.HTMLEscapeString(strings.Join(words, " ")))
+}
+
+// readingTime estimates minutes needed to read the specified count of words.
+func readingTime(wordCount int) int {
+	if wordCount <= 0 {
+		return 0
+	}
+
+	return (wordCount + wordsPerMinute - 1) / wordsPerMinute
+}
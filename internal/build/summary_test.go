@@ -0,0 +1,120 @@
+package build
+
+import (
+	"os"
+	fp "path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+func TestSplitSummary(t *testing.T) {
+	tests := []struct {
+		src             string
+		expectedSummary string
+		expectedContent string
+		hasSummary      bool
+	}{
+		{"no divider", "", "no divider", false},
+		{"intro\n<!--more-->\nrest", "intro\n", "intro\n\nrest", true},
+		{"<!--more-->rest", "", "rest", true},
+		{"a<!--more-->b<!--more-->c", "a", "ab<!--more-->c", true},
+	}
+
+	for _, test := range tests {
+		summary, content := splitSummary([]byte(test.src))
+		if (summary != nil) != test.hasSummary {
+			t.Errorf("splitSummary(%q): expected has summary %v, got %q", test.src, test.hasSummary, summary)
+			continue
+		}
+
+		if string(summary) != test.expectedSummary {
+			t.Errorf("splitSummary(%q): expected summary %q, got %q", test.src, test.expectedSummary, summary)
+		}
+
+		if string(content) != test.expectedContent {
+			t.Errorf("splitSummary(%q): expected content %q, got %q", test.src, test.expectedContent, content)
+		}
+	}
+}
+
+func TestSplitSummaryDoesntModifySource(t *testing.T) {
+	src := []byte("intro<!--more-->rest")
+	summary, _ := splitSummary(src)
+	_ = append(summary, "changed"...)
+
+	if string(src) != "intro<!--more-->rest" {
+		t.Errorf("source is modified into %q", src)
+	}
+}
+
+func TestSummarizeText(t *testing.T) {
+	tests := []struct {
+		text     string
+		n        int
+		expected string
+	}{
+		{"one two three", 5, "one two three"},
+		{"one two three", 2, "one two…"},
+		{"one  two\nthree", 3, "one two three"},
+		{"<b> & co", 5, "&lt;b&gt; &amp; co"},
+	}
+
+	for _, test := range tests {
+		if result := summarizeText(test.text, test.n); string(result) != test.expected {
+			t.Errorf("summarizeText(%q, %d): expected %q, got %q", test.text, test.n, test.expected, result)
+		}
+	}
+}
+
+func TestSummaryWithHeadingAndTOC(t *testing.T) {
+	rootDir := t.TempDir()
+	contentDir := fp.Join(rootDir, "content")
+	if err := os.MkdirAll(contentDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fp.Join(contentDir, "_index.md"), []byte("+++\nTitle = \"Test\"\n+++\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wk, err := NewWorker(rootDir, Config{})
+	if err != nil {
+		t.Fatalf("failed to create worker: %v", err)
+	}
+
+	src := "## Intro\n\n[[toc]]\n\nShort intro.\n\n<!--more-->\n\n## Body\n\nText.\n"
+	mdPath := fp.Join(contentDir, "post.md")
+	content, err := wk.renderMarkdown([]byte(src), mdPath, model.Metadata{})
+	if err != nil {
+		t.Fatalf("failed to render markdown: %v", err)
+	}
+
+	// Summary doesn't have heading ID nor table of contents
+	expectedSummary := "<h2>Intro</h2>\n<p>Short intro.</p>\n"
+	if string(content.Summary) != expectedSummary {
+		t.Errorf("expected summary %q, got %q", expectedSummary, content.Summary)
+	}
+
+	// Content has the inline table of contents, so TOC is left empty
+	html := string(content.HTML)
+	if strings.Count(html, `<nav class="toc">`) != 1 || !strings.Contains(html, `<h2 id="intro">`) {
+		t.Errorf("expected content with heading ID and inline TOC, got %s", html)
+	}
+
+	if len(content.TOC) != 0 {
+		t.Errorf("expected empty TOC since it's rendered inline, got %+v", content.TOC)
+	}
+
+	// Without the marker, TOC is available for the theme
+	content, err = wk.renderMarkdown([]byte("## Intro\n\nText.\n"), mdPath, model.Metadata{})
+	if err != nil {
+		t.Fatalf("failed to render markdown: %v", err)
+	}
+
+	expectedTOC := []model.TOCItem{{Level: 2, ID: "intro", Text: "Intro"}}
+	if len(content.TOC) != 1 || content.TOC[0].ID != expectedTOC[0].ID || content.TOC[0].Text != expectedTOC[0].Text {
+		t.Errorf("expected TOC %+v, got %+v", expectedTOC, content.TOC)
+	}
+}
@@ -0,0 +1,385 @@
+package build
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	fp "path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+)
+
+// tagDescDir is name of the directory that contains description of tags,
+// e.g. `_tags/go.md` for tag `go`. It's not part of site content.
+const tagDescDir = "_tags"
+
+// tagAliases returns map of tag alias to its canonical name, which specified
+// in root metadata. The alias is lowercased so it's case insensitive.
+func (wk *Worker) tagAliases() map[string]string {
+	rootMeta, err := wk.rawMeta(fp.Join(wk.ContentDir, "_index.md"))
+	if err != nil {
+		return nil
+	}
+
+	aliases := make(map[string]string, len(rootMeta.TagAliases))
+	for alias, name := range rootMeta.TagAliases {
+		aliases[strings.ToLower(strings.TrimSpace(alias))] = strings.TrimSpace(name)
+	}
+
+	return aliases
+}
+
+// normalizeTags resolves alias of each tag, then returns the display names
+// along with its slugs. Tags with the same slug are only returned once, while
+// tags that doesn't have any letter or digit are skipped.
+func (wk *Worker) normalizeTags(tags []string) (names []string, slugs []string) {
+	aliases := wk.tagAliases()
+	existingSlugs := make(map[string]struct{})
+	for _, tag := range tags {
+		name, slug := normalizeTag(tag, aliases)
+		if _, exist := existingSlugs[slug]; exist || slug == "" {
+			continue
+		}
+
+		existingSlugs[slug] = struct{}{}
+		names = append(names, name)
+		slugs = append(slugs, slug)
+	}
+
+	return
+}
+
+// normalizeTag resolves alias of the tag, then returns its display name and
+// slug. Tag that contains `/` is hierarchical, so each of its segment is
+// slugified separately, e.g. `Lang/Go` into `lang/go`.
+func normalizeTag(tag string, aliases map[string]string) (name string, slug string) {
+	tag = strings.TrimSpace(tag)
+	if canonical, isAlias := aliases[strings.ToLower(tag)]; isAlias {
+		tag = canonical
+	}
+
+	// Slugify each segment, skipping the empty one
+	var nameSegments, slugSegments []string
+	for _, segment := range strings.Split(tag, "/") {
+		segmentSlug := slugify(segment)
+		if segmentSlug == "" {
+			continue
+		}
+
+		nameSegments = append(nameSegments, strings.TrimSpace(segment))
+		slugSegments = append(slugSegments, segmentSlug)
+	}
+
+	return strings.Join(nameSegments, "/"), strings.Join(slugSegments, "/")
+}
+
+// tagSlug returns slug of a tag, after its alias resolved.
+func (wk *Worker) tagSlug(tag string) string {
+	_, slugs := wk.normalizeTags([]string{tag})
+	if len(slugs) == 0 {
+		return ""
+	}
+	return slugs[0]
+}
+
+// splitTagURL splits URL path of tag files into its directory and the tag
+// after the first `tag-` segment, e.g. `blog/tag-lang/go` into `blog` and
+// `lang/go`. If the URL path is in root, the directory will be `.`.
+func splitTagURL(urlPath string) (dirURLPath string, tag string) {
+	dirURLPath = "."
+	segments := strings.Split(urlPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "tag-") {
+			dirURLPath = path.Join(segments[:i]...)
+			tag = strings.TrimPrefix(path.Join(segments[i:]...), "tag-")
+			break
+		}
+	}
+
+	if dirURLPath == "" {
+		dirURLPath = "."
+	}
+
+	return
+}
+
+// tagIncludes checks whether tag with the specified slug is the active tag,
+// or one of its descendants.
+func tagIncludes(activeSlug string, slug string) bool {
+	return slug == activeSlug || strings.HasPrefix(slug, activeSlug+"/")
+}
+
+// tagDescription looks for description of tag in `_tags` directory, starting
+// from the directory in specified path up to the root of content. The file
+// name is compared by its slug, so `_tags/Web Dev.md` is used for `web-dev`.
+func (wk *Worker) tagDescription(dirPath string, tagSlug string) (model.Metadata, renderedContent, bool, error) {
+	for dir := dirPath; ; dir = fp.Dir(dir) {
+		descFiles, err := wk.tagDescFiles(dir)
+		if err != nil {
+			return model.Metadata{}, renderedContent{}, false, err
+		}
+
+		if descPath, exist := descFiles[tagSlug]; exist {
+			meta, content, err := wk.parsePath(descPath)
+			return meta, content, err == nil, err
+		}
+
+		if dir == wk.ContentDir || dir == fp.Dir(dir) {
+			break
+		}
+	}
+
+	return model.Metadata{}, renderedContent{}, false, nil
+}
+
+// tagDescFiles returns path of description files in `_tags` directory of the
+// specified dir, mapped by slug of its tag. If several files share the same
+// slug, the first one in lexical order is used. Since it's needed by every tag
+// page, the result is cached when cache is enabled.
+func (wk *Worker) tagDescFiles(dirPath string) (map[string]string, error) {
+	if wk.cacheEnabled {
+		if descFiles, cached := wk.tagDescCache[dirPath]; cached {
+			return descFiles, nil
+		}
+	}
+
+	aliases := wk.tagAliases()
+	descFiles := make(map[string]string)
+	descDir := fp.Join(dirPath, tagDescDir)
+	err := fp.WalkDir(descDir, func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || fp.Ext(fPath) != ".md" {
+			return err
+		}
+
+		relPath, err := fp.Rel(descDir, strings.TrimSuffix(fPath, ".md"))
+		if err != nil {
+			return err
+		}
+
+		_, slug := normalizeTag(fp.ToSlash(relPath), aliases)
+		if _, exist := descFiles[slug]; !exist && slug != "" {
+			descFiles[slug] = fPath
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if wk.cacheEnabled {
+		wk.tagDescCache[dirPath] = descFiles
+	}
+
+	return descFiles, nil
+}
+
+// newTagPath creates TagPath for a tag, along with its ancestors as Parent.
+// The URL path is generated using tagURL, which receives slug of the tag.
+func newTagPath(name string, slug string, tagURL func(string) string) model.TagPath {
+	tp := model.TagPath{
+		URLPath: tagURL(slug),
+		Name:    name,
+	}
+
+	if parentSlug := path.Dir(slug); parentSlug != "." {
+		parent := newTagPath(path.Dir(name), parentSlug, tagURL)
+		tp.Parent = &parent
+	}
+
+	return tp
+}
+
+// tagPaths returns TagPath for each tag that scoped to the dir in specified
+// URL path, sorted by its name.
+func (wk *Worker) tagPaths(dirURLPath string, tags []string) []model.TagPath {
+	tagURL := func(slug string) string {
+		return path.Join("/", dirURLPath, "tag-"+slug)
+	}
+
+	names, slugs := wk.normalizeTags(tags)
+	var paths []model.TagPath
+	for i, name := range names {
+		paths = append(paths, newTagPath(name, slugs[i], tagURL))
+	}
+
+	sort.Slice(paths, func(a, b int) bool {
+		return strings.ToLower(paths[a].Name) < strings.ToLower(paths[b].Name)
+	})
+
+	return paths
+}
+
+// tagCounter counts files for each tag slug, along with the display names
+// that used for it.
+type tagCounter struct {
+	counts map[string]int
+	names  map[string]map[string]int
+}
+
+func newTagCounter() tagCounter {
+	return tagCounter{
+		counts: make(map[string]int),
+		names:  make(map[string]map[string]int),
+	}
+}
+
+// Add records a file that uses the tag.
+func (c tagCounter) Add(name string, slug string) {
+	if c.names[slug] == nil {
+		c.names[slug] = make(map[string]int)
+	}
+
+	c.counts[slug]++
+	c.names[slug][name]++
+}
+
+// AddFile records a file that uses the tags. Since hierarchical tag includes
+// its descendants, the file is recorded for ancestors of each tag as well, but
+// only once for each of them.
+func (c tagCounter) AddFile(names []string, slugs []string) {
+	added := make(map[string]struct{})
+	for i := range names {
+		nameSegments := strings.Split(names[i], "/")
+		slugSegments := strings.Split(slugs[i], "/")
+		for j := len(slugSegments); j > 0; j-- {
+			slug := strings.Join(slugSegments[:j], "/")
+			if _, exist := added[slug]; exist {
+				continue
+			}
+
+			added[slug] = struct{}{}
+			c.Add(strings.Join(nameSegments[:j], "/"), slug)
+		}
+	}
+}
+
+// Slugs returns every recorded slug, mapped to count of files using it.
+func (c tagCounter) Slugs() map[string]int {
+	return c.counts
+}
+
+// Name returns display name for the slug, which is the most used one. If
+// there are several, the first one in alphabetical order is used.
+func (c tagCounter) Name(slug string) string {
+	bestName, bestCount := "", 0
+	for name, count := range c.names[slug] {
+		if count > bestCount || (count == bestCount && name < bestName) {
+			bestName, bestCount = name, count
+		}
+	}
+	return bestName
+}
+
+// TagPaths returns every recorded tag as a flat list sorted by count of its
+// files, and as a tree sorted by its name. The URL path is generated using
+// tagURL, which receives slug of the tag.
+func (c tagCounter) TagPaths(tagURL func(string) string) (list []model.TagPath, tree []model.TagPath) {
+	// Group slugs by its parent
+	children := make(map[string][]string)
+	for slug := range c.counts {
+		children[path.Dir(slug)] = append(children[path.Dir(slug)], slug)
+	}
+
+	// Create the tree, starting from the root tags
+	var createNodes func(parent *model.TagPath, parentSlug string) []model.TagPath
+	createNodes = func(parent *model.TagPath, parentSlug string) []model.TagPath {
+		var nodes []model.TagPath
+		for _, slug := range children[parentSlug] {
+			node := model.TagPath{
+				URLPath: tagURL(slug),
+				Name:    c.Name(slug),
+				Count:   c.counts[slug],
+				Parent:  parent,
+			}
+
+			// Children only receives shallow copy of its parent
+			shallow := node
+			node.Children = createNodes(&shallow, slug)
+			nodes = append(nodes, node)
+		}
+
+		sort.Slice(nodes, func(a, b int) bool {
+			return strings.ToLower(nodes[a].Name) < strings.ToLower(nodes[b].Name)
+		})
+
+		return nodes
+	}
+
+	tree = createNodes(nil, ".")
+
+	// Flatten the tree
+	var flatten func(nodes []model.TagPath)
+	flatten = func(nodes []model.TagPath) {
+		for _, node := range nodes {
+			list = append(list, node)
+			flatten(node.Children)
+		}
+	}
+
+	flatten(tree)
+	sort.SliceStable(list, func(a, b int) bool {
+		countA := list[a].Count
+		countB := list[b].Count
+		if countA != countB {
+			return countA > countB
+		}
+
+		nameA := list[a].Name
+		nameB := list[b].Name
+		return strings.ToLower(nameA) < strings.ToLower(nameB)
+	})
+
+	return list, tree
+}
+
+// Collisions returns slugs that used by different display names, ignoring
+// the difference in letter case, mapped to the sorted display names.
+func (c tagCounter) Collisions() map[string][]string {
+	collisions := make(map[string][]string)
+	for slug, names := range c.names {
+		distinctNames := []string{}
+		for name := range names {
+			isVariant := false
+			for _, existing := range distinctNames {
+				if strings.EqualFold(existing, name) {
+					isVariant = true
+					break
+				}
+			}
+
+			if !isVariant {
+				distinctNames = append(distinctNames, name)
+			}
+		}
+
+		if len(distinctNames) > 1 {
+			sort.Strings(distinctNames)
+			collisions[slug] = distinctNames
+		}
+	}
+
+	return collisions
+}
+
+// recordTagCollisions saves the tag collisions, so it can be reported after
+// the site is built. Like removed raw HTML, it's skipped when cache is
+// disabled since the worker might be shared by concurrent requests.
+func (wk *Worker) recordTagCollisions(c tagCounter) {
+	if !wk.cacheEnabled {
+		return
+	}
+
+	for slug, names := range c.Collisions() {
+		wk.tagCollisions[slug] = names
+	}
+}
+
+// TagCollisions returns slugs that shared by different tags, mapped to the
+// names of those tags. It's only recorded when cache is enabled.
+func (wk *Worker) TagCollisions() map[string][]string {
+	return wk.tagCollisions
+}
@@ -0,0 +1,269 @@
+package build
+
+import (
+	"os"
+	fp "path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestWorker creates a site in temporary dir with the specified content
+// files, mapped by its path relative to content dir, then creates a worker
+// for it.
+func newTestWorker(t *testing.T, files map[string]string) Worker {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	if _, exist := files["_index.md"]; !exist {
+		files["_index.md"] = "+++\nTitle = \"Test\"\n+++\n"
+	}
+
+	for name, content := range files {
+		fPath := fp.Join(rootDir, "content", fp.FromSlash(name))
+		if err := os.MkdirAll(fp.Dir(fPath), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wk, err := NewWorker(rootDir, Config{})
+	if err != nil {
+		t.Fatalf("failed to create worker: %v", err)
+	}
+
+	return wk
+}
+
+func TestNormalizeTags(t *testing.T) {
+	wk := newTestWorker(t, map[string]string{
+		"_index.md": "+++\nTitle = \"Test\"\n[TagAliases]\ngolang = \"Go\"\n+++\n",
+	})
+
+	tests := []struct {
+		tags          []string
+		expectedNames []string
+		expectedSlugs []string
+	}{
+		{[]string{"Web Dev", "Ünïcode"}, []string{"Web Dev", "Ünïcode"}, []string{"web-dev", "ünïcode"}},
+		{[]string{"Go", "go", "GO"}, []string{"Go"}, []string{"go"}},
+		{[]string{" golang ", "Go"}, []string{"Go"}, []string{"go"}},
+		{[]string{"GoLang"}, []string{"Go"}, []string{"go"}},
+		{[]string{"C", "C#", "C++"}, []string{"C"}, []string{"c"}},
+		{[]string{"!!!", "", "Go"}, []string{"Go"}, []string{"go"}},
+	}
+
+	for _, test := range tests {
+		names, slugs := wk.normalizeTags(test.tags)
+		if !reflect.DeepEqual(names, test.expectedNames) || !reflect.DeepEqual(slugs, test.expectedSlugs) {
+			t.Errorf("normalizeTags(%q): expected %q %q, got %q %q",
+				test.tags, test.expectedNames, test.expectedSlugs, names, slugs)
+		}
+	}
+}
+
+func TestTagCounterName(t *testing.T) {
+	c := newTagCounter()
+	c.Add("go", "go")
+	c.Add("Go", "go")
+	c.Add("Go", "go")
+	c.Add("C#", "c")
+	c.Add("C", "c")
+
+	tests := []struct {
+		slug     string
+		expected string
+	}{
+		{"go", "Go"},
+		{"c", "C"},
+		{"rust", ""},
+	}
+
+	for _, test := range tests {
+		if name := c.Name(test.slug); name != test.expected {
+			t.Errorf("name of %q: expected %q, got %q", test.slug, test.expected, name)
+		}
+	}
+}
+
+func TestTagCollisions(t *testing.T) {
+	c := newTagCounter()
+	c.Add("Go", "go")
+	c.Add("go", "go")
+	c.Add("C", "c")
+	c.Add("C#", "c")
+	c.Add("C++", "c")
+
+	expected := map[string][]string{"c": {"C", "C#", "C++"}}
+	if collisions := c.Collisions(); !reflect.DeepEqual(collisions, expected) {
+		t.Errorf("expected collisions %v, got %v", expected, collisions)
+	}
+}
+
+func TestNormalizeHierarchicalTags(t *testing.T) {
+	wk := newTestWorker(t, map[string]string{})
+
+	tests := []struct {
+		tags          []string
+		expectedNames []string
+		expectedSlugs []string
+	}{
+		{[]string{"Lang/Go"}, []string{"Lang/Go"}, []string{"lang/go"}},
+		{[]string{" lang / Go ", "Lang/go"}, []string{"lang/Go"}, []string{"lang/go"}},
+		{[]string{"/lang//go/"}, []string{"lang/go"}, []string{"lang/go"}},
+		{[]string{"lang/!!!/go"}, []string{"lang/go"}, []string{"lang/go"}},
+		{[]string{"Lang", "Lang/Go"}, []string{"Lang", "Lang/Go"}, []string{"lang", "lang/go"}},
+	}
+
+	for _, test := range tests {
+		names, slugs := wk.normalizeTags(test.tags)
+		if !reflect.DeepEqual(names, test.expectedNames) || !reflect.DeepEqual(slugs, test.expectedSlugs) {
+			t.Errorf("normalizeTags(%q): expected %q %q, got %q %q",
+				test.tags, test.expectedNames, test.expectedSlugs, names, slugs)
+		}
+	}
+}
+
+func TestSplitTagURL(t *testing.T) {
+	tests := []struct {
+		urlPath     string
+		expectedDir string
+		expectedTag string
+	}{
+		{"tag-go", ".", "go"},
+		{"blog/tag-go", "blog", "go"},
+		{"blog/tag-lang/go", "blog", "lang/go"},
+		{"blog/2023/tag-lang/go/2", "blog/2023", "lang/go/2"},
+		{"blog/tag-lang/tag-go", "blog", "lang/tag-go"},
+		{"blog/post", ".", ""},
+	}
+
+	for _, test := range tests {
+		dir, tag := splitTagURL(test.urlPath)
+		if dir != test.expectedDir || tag != test.expectedTag {
+			t.Errorf("splitTagURL(%q): expected %q %q, got %q %q",
+				test.urlPath, test.expectedDir, test.expectedTag, dir, tag)
+		}
+	}
+}
+
+func TestNewTagPath(t *testing.T) {
+	tagURL := func(slug string) string {
+		return "/blog/tag-" + slug
+	}
+
+	tp := newTagPath("Lang/Go/Generics", "lang/go/generics", tagURL)
+	expected := []struct{ urlPath, name string }{
+		{"/blog/tag-lang/go/generics", "Lang/Go/Generics"},
+		{"/blog/tag-lang/go", "Lang/Go"},
+		{"/blog/tag-lang", "Lang"},
+	}
+
+	current := &tp
+	for _, exp := range expected {
+		if current == nil {
+			t.Fatalf("expected tag %s, got nil parent", exp.urlPath)
+		}
+
+		if current.URLPath != exp.urlPath || current.Name != exp.name {
+			t.Errorf("expected tag %q %q, got %q %q", exp.urlPath, exp.name, current.URLPath, current.Name)
+		}
+
+		current = current.Parent
+	}
+
+	if current != nil {
+		t.Errorf("expected root tag without parent, got %+v", current)
+	}
+}
+
+func TestTagCounterAddFile(t *testing.T) {
+	c := newTagCounter()
+	c.AddFile([]string{"A/B/C", "A/B/D"}, []string{"a/b/c", "a/b/d"})
+	c.AddFile([]string{"A/B"}, []string{"a/b"})
+	c.AddFile([]string{"A/B/C", "a/b/c", "A"}, []string{"a/b/c", "a/b/c", "a"})
+	c.AddFile([]string{"E"}, []string{"e"})
+
+	expected := map[string]int{
+		"a":     3,
+		"a/b":   3,
+		"a/b/c": 2,
+		"a/b/d": 1,
+		"e":     1,
+	}
+
+	if counts := c.Slugs(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected counts %v, got %v", expected, counts)
+	}
+
+	// Ancestor is named from the segment of its descendants
+	if name := c.Name("a/b"); name != "A/B" {
+		t.Errorf("expected name A/B, got %q", name)
+	}
+
+	// Tree only contains root tags, with descendants as its children
+	list, tree := c.TagPaths(func(slug string) string { return "/tags/" + slug })
+	if len(list) != len(expected) {
+		t.Errorf("expected %d tags in list, got %d", len(expected), len(list))
+	}
+
+	if len(tree) != 2 || tree[0].Name != "A" || tree[1].Name != "E" {
+		t.Fatalf("expected root tags A and E, got %+v", tree)
+	}
+
+	ab := tree[0].Children
+	if len(ab) != 1 || ab[0].URLPath != "/tags/a/b" || ab[0].Count != 3 {
+		t.Fatalf("expected A/B as the only child of A, got %+v", ab)
+	}
+
+	leaves := ab[0].Children
+	if len(leaves) != 2 || leaves[0].Name != "A/B/C" || leaves[1].Name != "A/B/D" {
+		t.Errorf("expected A/B/C and A/B/D as children of A/B, got %+v", leaves)
+	}
+
+	if leaves[0].Parent == nil || leaves[0].Parent.URLPath != "/tags/a/b" {
+		t.Errorf("expected A/B as parent of A/B/C, got %+v", leaves[0].Parent)
+	}
+}
+
+func TestTagDescription(t *testing.T) {
+	wk := newTestWorker(t, map[string]string{
+		"_index.md":            "+++\nTitle = \"Test\"\n[TagAliases]\ngolang = \"Go\"\n+++\n",
+		"_tags/Web Dev.md":     "+++\nDescription = \"root web\"\n+++\n",
+		"_tags/golang.md":      "+++\nDescription = \"root go\"\n+++\n",
+		"_tags/lang/Rust.md":   "+++\nDescription = \"root rust\"\n+++\n",
+		"blog/_index.md":       "+++\nTitle = \"Blog\"\n+++\n",
+		"blog/_tags/go.md":     "+++\nDescription = \"blog go\"\n+++\n",
+		"blog/_tags/notes.txt": "not a description",
+	})
+
+	tests := []struct {
+		dir      string
+		slug     string
+		expected string
+		found    bool
+	}{
+		{"blog", "go", "blog go", true},
+		{"blog", "web-dev", "root web", true},
+		{"blog", "lang/rust", "root rust", true},
+		{".", "go", "root go", true},
+		{".", "notes", "", false},
+		{"blog", "python", "", false},
+	}
+
+	for _, test := range tests {
+		dirPath := fp.Join(wk.ContentDir, test.dir)
+		meta, _, found, err := wk.tagDescription(dirPath, test.slug)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.dir, test.slug, err)
+			continue
+		}
+
+		if found != test.found || meta.Description != test.expected {
+			t.Errorf("%s %s: expected %v %q, got %v %q",
+				test.dir, test.slug, test.found, test.expected, found, meta.Description)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package build
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	fp "path/filepath"
+	"sort"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/RadhiFadlillah/boom/internal/model"
+	"github.com/pelletier/go-toml"
+)
+
+// DefaultThemeName is the name of default theme that embedded in boom. It's
+// used when there are no theme in site's themes dir.
+const DefaultThemeName = "default"
+
+//go:embed default-theme
+var defaultThemeFiles embed.FS
+
+// DefaultThemeFS returns file system of the embedded default theme.
+func DefaultThemeFS() fs.FS {
+	fsys, _ := fs.Sub(defaultThemeFiles, "default-theme")
+	return fsys
+}
+
+// Theme is a theme that ready to be used for rendering. Since a theme might
+// inherit another theme, it consists of several layers which ordered from the
+// theme itself up to its farthest ancestor. When a file is requested, the first
+// layer that has it will be used.
+type Theme struct {
+	Name   string
+	Layers []ThemeLayer
+}
+
+// ThemeLayer is a single theme within inheritance chain of a theme.
+// For the embedded default theme, Dir will be empty.
+type ThemeLayer struct {
+	Name string
+	Dir  string
+	FS   fs.FS
+}
+
+// LoadTheme loads theme with specified name along with its ancestors from
+// themes dir of the site in root dir. If name is empty, the first theme
+// found in themes dir will be used, or the embedded default theme if there
+// are no theme at all.
+func LoadTheme(rootDir string, name string) (Theme, error) {
+	// If theme name not specified, use the first dir found
+	themesDir := fp.Join(rootDir, "themes")
+	if name == "" && fileutils.IsDir(themesDir) {
+		dirItems, err := os.ReadDir(themesDir)
+		if err != nil {
+			return Theme{}, err
+		}
+
+		for _, item := range dirItems {
+			if item.IsDir() {
+				name = item.Name()
+				break
+			}
+		}
+	}
+
+	// If there are still no theme, use the default one
+	if name == "" {
+		name = DefaultThemeName
+	}
+
+	// Follow the parent of each theme
+	theme := Theme{Name: name}
+	visited := make(map[string]struct{})
+
+	for layerName := name; layerName != ""; {
+		if _, exist := visited[layerName]; exist {
+			return Theme{}, fmt.Errorf("theme %s has circular parent", name)
+		}
+		visited[layerName] = struct{}{}
+
+		// If default theme is not ejected into themes dir, use the embedded one.
+		// It doesn't have any parent, so we can stop here.
+		layerDir := fp.Join(themesDir, layerName)
+		if layerName == DefaultThemeName && !fileutils.IsDir(layerDir) {
+			theme.Layers = append(theme.Layers, ThemeLayer{
+				Name: layerName,
+				FS:   DefaultThemeFS(),
+			})
+			break
+		}
+
+		if !fileutils.IsDir(layerDir) {
+			return Theme{}, fmt.Errorf("theme %s doesn't exist", layerName)
+		}
+
+		themeMeta, err := parseThemeMetadata(layerDir)
+		if err != nil {
+			return Theme{}, fmt.Errorf("failed to parse metadata of theme %s: %w", layerName, err)
+		}
+
+		theme.Layers = append(theme.Layers, ThemeLayer{
+			Name: layerName,
+			Dir:  layerDir,
+			FS:   os.DirFS(layerDir),
+		})
+
+		layerName = themeMeta.Parent
+	}
+
+	return theme, nil
+}
+
+// parseThemeMetadata parses `theme.toml` file in specified theme dir.
+// If the file doesn't exist, empty metadata will be returned.
+func parseThemeMetadata(themeDir string) (meta model.ThemeMetadata, err error) {
+	metaPath := fp.Join(themeDir, "theme.toml")
+	if !fileutils.IsFile(metaPath) {
+		return
+	}
+
+	f, err := os.Open(metaPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	err = toml.NewDecoder(f).Decode(&meta)
+	return
+}
+
+// Open opens the named file from the first layer that has it.
+func (t Theme) Open(name string) (fs.File, error) {
+	for _, layer := range t.Layers {
+		f, err := layer.FS.Open(name)
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir reads the named directory from every layer, then merge their
+// entries. If several layers have entry with the same name, the one from
+// the nearest layer will be used.
+func (t Theme) ReadDir(name string) ([]fs.DirEntry, error) {
+	found := false
+	mapEntries := make(map[string]fs.DirEntry)
+
+	for i := len(t.Layers) - 1; i >= 0; i-- {
+		entries, err := fs.ReadDir(t.Layers[i].FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+		for _, entry := range entries {
+			mapEntries[entry.Name()] = entry
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(mapEntries))
+	for _, entry := range mapEntries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Name() < entries[b].Name()
+	})
+
+	return entries, nil
+}
+
+// Locate returns the layer which provides the named file.
+func (t Theme) Locate(name string) (ThemeLayer, bool) {
+	for _, layer := range t.Layers {
+		if _, err := fs.Stat(layer.FS, name); err == nil {
+			return layer, true
+		}
+	}
+
+	return ThemeLayer{}, false
+}
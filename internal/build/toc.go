@@ -0,0 +1,199 @@
+package build
+
+import (
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/model"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Default heading levels that included in table of contents.
+const (
+	defaultTOCStartLevel = 2
+	defaultTOCEndLevel   = 3
+)
+
+// tocMarker is the paragraph that will be replaced by table of contents.
+const tocMarker = "[[toc]]"
+
+var kindTOC = ast.NewNodeKind("TOC")
+
+// tocNode is the place where table of contents rendered inline.
+type tocNode struct {
+	ast.BaseBlock
+}
+
+// Kind implements ast.Node.
+func (n *tocNode) Kind() ast.NodeKind {
+	return kindTOC
+}
+
+// Dump implements ast.Node.
+func (n *tocNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// tocCollector collects headings from markdown document into table of
+// contents, then replaces `[[toc]]` marker with it. In summary the marker is
+// simply removed, since summary is shown outside of its page.
+type tocCollector struct {
+	startLevel int
+	endLevel   int
+	isSummary  bool
+	shortcodes *shortcodeResolver
+	items      []model.TOCItem
+	hasMarker  bool
+}
+
+func newTOCCollector(cfg model.MarkdownConfig, shortcodes *shortcodeResolver, isSummary bool) *tocCollector {
+	c := &tocCollector{
+		startLevel: cfg.TOCStartLevel,
+		endLevel:   cfg.TOCEndLevel,
+		isSummary:  isSummary,
+		shortcodes: shortcodes,
+	}
+
+	if c.startLevel <= 0 {
+		c.startLevel = defaultTOCStartLevel
+	}
+
+	if c.endLevel <= 0 {
+		c.endLevel = defaultTOCEndLevel
+	}
+
+	return c
+}
+
+// Transform implements parser.ASTTransformer.
+func (c *tocCollector) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	headings := []model.TOCItem{}
+	markers := []ast.Node{}
+
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Heading:
+			// Heading with shortcode still contains its placeholder, so use
+			// the shortcode text for its ID and TOC instead
+			text := string(n.Text(source))
+			if restored := c.shortcodes.plainText(text); restored != text {
+				text = restored
+				if id, exist := n.AttributeString("id"); exist && isPlaceholderID(id) {
+					n.SetAttributeString("id", pc.IDs().Generate([]byte(text), ast.KindHeading))
+				}
+			}
+
+			if n.Level < c.startLevel || n.Level > c.endLevel {
+				return ast.WalkSkipChildren, nil
+			}
+
+			item := model.TOCItem{
+				Level: n.Level,
+				Text:  text,
+			}
+
+			if id, exist := n.AttributeString("id"); exist {
+				if bt, isBytes := id.([]byte); isBytes {
+					item.ID = string(bt)
+				}
+			}
+
+			headings = append(headings, item)
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Paragraph:
+			if strings.TrimSpace(string(n.Text(source))) == tocMarker {
+				markers = append(markers, n)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	c.items = nestTOCItems(headings)
+
+	for _, marker := range markers {
+		if c.isSummary {
+			marker.Parent().RemoveChild(marker.Parent(), marker)
+			continue
+		}
+
+		marker.Parent().ReplaceChild(marker.Parent(), marker, &tocNode{})
+		c.hasMarker = true
+	}
+}
+
+// isPlaceholderID checks whether heading ID is generated from text that
+// contains shortcode placeholder.
+func isPlaceholderID(id interface{}) bool {
+	bt, isBytes := id.([]byte)
+	prefix := strings.ToLower(shortcodePlaceholderPrefix)
+	return isBytes && strings.Contains(strings.ToLower(string(bt)), prefix)
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (c *tocCollector) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindTOC, c.renderTOC)
+}
+
+func (c *tocCollector) renderTOC(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering && len(c.items) > 0 {
+		w.WriteString(`<nav class="toc">` + "\n")
+		writeTOCList(w, c.items)
+		w.WriteString("</nav>\n")
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+// writeTOCList writes TOC items as nested list.
+func writeTOCList(w util.BufWriter, items []model.TOCItem) {
+	w.WriteString("<ul>\n")
+	for _, item := range items {
+		w.WriteString("<li>")
+		if item.ID != "" {
+			w.WriteString(`<a href="#`)
+			w.Write(util.EscapeHTML([]byte(item.ID)))
+			w.WriteString(`">`)
+			w.Write(util.EscapeHTML([]byte(item.Text)))
+			w.WriteString("</a>")
+		} else {
+			w.Write(util.EscapeHTML([]byte(item.Text)))
+		}
+
+		if len(item.Children) > 0 {
+			w.WriteString("\n")
+			writeTOCList(w, item.Children)
+		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString("</ul>\n")
+}
+
+// nestTOCItems converts flat list of headings into tree, where each heading
+// becomes child of the nearest previous heading with lower level.
+func nestTOCItems(headings []model.TOCItem) []model.TOCItem {
+	root := &model.TOCItem{}
+	stack := []*model.TOCItem{root}
+
+	for _, heading := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, heading)
+		stack = append(stack, &parent.Children[len(parent.Children)-1])
+	}
+
+	return root.Children
+}
@@ -1,17 +1,9 @@
 package build
 
 import (
-	"bytes"
 	"strconv"
-
-	chromahtml "github.com/alecthomas/chroma/formatters/html"
-	mathjax "github.com/litao91/goldmark-mathjax"
-	"github.com/yuin/goldmark"
-	emoji "github.com/yuin/goldmark-emoji"
-	highlighting "github.com/yuin/goldmark-highlighting"
-	"github.com/yuin/goldmark/extension"
-	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/renderer/html"
+	"strings"
+	"unicode"
 )
 
 func isNumber(str string) (bool, int) {
@@ -22,34 +14,24 @@ func isNumber(str string) (bool, int) {
 	return true, num
 }
 
-func convertMarkdownToHTML(bt []byte) ([]byte, error) {
-	highlighter := highlighting.NewHighlighting(
-		highlighting.WithFormatOptions(
-			chromahtml.WithClasses(true),
-		),
-	)
+// slugify converts string into URL friendly slug. Letters and digits are
+// lowercased, while the other characters are replaced by a single dash.
+func slugify(str string) string {
+	var sb strings.Builder
+	needDash := false
+	for _, r := range str {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			needDash = sb.Len() > 0
+			continue
+		}
 
-	md := goldmark.New(
-		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
-		goldmark.WithExtensions(
-			extension.GFM,
-			extension.DefinitionList,
-			extension.Footnote,
-			emoji.Emoji,
-			highlighter,
-			mathjax.MathJax,
-		),
-		goldmark.WithRendererOptions(
-			html.WithHardWraps(),
-			html.WithXHTML(),
-		),
-	)
+		if needDash {
+			sb.WriteRune('-')
+			needDash = false
+		}
 
-	buf := bytes.NewBuffer(nil)
-	err := md.Convert(bt, buf)
-	if err != nil {
-		return nil, err
+		sb.WriteRune(unicode.ToLower(r))
 	}
 
-	return buf.Bytes(), nil
+	return sb.String()
 }
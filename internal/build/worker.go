@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"html/template"
 	"io"
-	"io/ioutil"
+	"io/fs"
 	"os"
+	"path"
 	fp "path/filepath"
 	"regexp"
 	"strings"
@@ -16,32 +18,65 @@ import (
 	"github.com/RadhiFadlillah/boom/internal/model"
 	"github.com/pelletier/go-toml"
 	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
 	"github.com/tdewolff/minify/v2/html"
 )
 
 var (
-	rxTagURL = regexp.MustCompile(`(?i)tag-([^\s/]+)(?:\/(\d+))?$`)
+	rxTagURL = regexp.MustCompile(`(?i)(?:^|/)tag-[^/]+(?:/[^/]+)*$`)
 )
 
+// ErrNotFound is error to notify that there are no page in the URL path.
+var ErrNotFound = errors.New("page not found")
+
 // Worker is the one that build markdown into HTML file.
 type Worker struct {
 	RootDir    string
 	ContentDir string
 
 	buildDraft   bool
+	buildFuture  bool
+	buildExpired bool
 	cacheEnabled bool
 	minifyOutput bool
 
-	minifier      *minify.M
-	metaCache     map[string]model.Metadata
-	htmlCache     map[string]template.HTML
-	templateCache map[string]*template.Template
+	minifier       *minify.M
+	metaCache      map[string]model.Metadata
+	rawMetaCache   map[string]model.Metadata
+	contentCache   map[string]renderedContent
+	templateCache  map[string]*template.Template
+	hookCache      map[string]*template.Template
+	shortcodeCache map[string]*template.Template
+	removedHTML    map[string][]string
+	tagCollisions  map[string][]string
+	termCache      map[string]termIndex
+	tagDescCache   map[string]map[string]string
+	seriesCache    map[string][]model.ContentPath
+
+	// Custom taxonomies of the site, which only read once from root metadata.
+	taxonomies []model.TaxonomyConfig
+
+	// Paths that being rendered by this worker. It's never modified once
+	// created, so it's safe to be shared between concurrent builds.
+	rendering map[string]struct{}
+}
+
+// renderedContent is markdown content of a file that rendered into HTML,
+// along with the data that collected while rendering it.
+type renderedContent struct {
+	HTML        template.HTML
+	TOC         []model.TOCItem
+	Summary     template.HTML
+	WordCount   int
+	ReadingTime int
 }
 
 // Config is configuration for Worker.
 type Config struct {
 	EnableCache  bool
 	BuildDraft   bool
+	BuildFuture  bool
+	BuildExpired bool
 	MinifyOutput bool
 }
 
@@ -62,26 +97,45 @@ func NewWorker(rootDir string, cfg Config) (wk Worker, err error) {
 	}
 
 	contentIndexPath := fp.Join(contentDir, "_index.md")
-	if _, _, err = wk.parseMarkdown(contentIndexPath); err != nil {
+	rootMeta, _, err := wk.parseMarkdown(contentIndexPath)
+	if err != nil {
 		return
 	}
 
 	// Create minifier
 	minifier := minify.New()
 	minifier.AddFunc("text/html", html.Minify)
+	minifier.AddFunc("text/css", css.Minify)
 
 	// Create a new worker
 	wk = Worker{
-		RootDir:       rootDir,
-		ContentDir:    contentDir,
-		buildDraft:    cfg.BuildDraft,
-		cacheEnabled:  cfg.EnableCache,
-		minifyOutput:  cfg.MinifyOutput,
-		minifier:      minifier,
-		metaCache:     make(map[string]model.Metadata),
-		htmlCache:     make(map[string]template.HTML),
-		templateCache: make(map[string]*template.Template),
+		RootDir:        rootDir,
+		ContentDir:     contentDir,
+		buildDraft:     cfg.BuildDraft,
+		buildFuture:    cfg.BuildFuture,
+		buildExpired:   cfg.BuildExpired,
+		cacheEnabled:   cfg.EnableCache,
+		minifyOutput:   cfg.MinifyOutput,
+		minifier:       minifier,
+		metaCache:      make(map[string]model.Metadata),
+		rawMetaCache:   make(map[string]model.Metadata),
+		contentCache:   make(map[string]renderedContent),
+		templateCache:  make(map[string]*template.Template),
+		hookCache:      make(map[string]*template.Template),
+		shortcodeCache: make(map[string]*template.Template),
+		removedHTML:    make(map[string][]string),
+		tagCollisions:  make(map[string][]string),
+		termCache:      make(map[string]termIndex),
+		tagDescCache:   make(map[string]map[string]string),
+		seriesCache:    make(map[string][]model.ContentPath),
+		taxonomies:     siteTaxonomies(rootMeta),
+	}
+
+	// Make sure content doesn't use URL of site wide tags
+	if rootMeta.SiteTags {
+		err = wk.checkSiteTags()
 	}
+
 	return
 }
 
@@ -89,6 +143,10 @@ func NewWorker(rootDir string, cfg Config) (wk Worker, err error) {
 // There are two possible URL path combination :
 // 1. It's pointed directly to content, e.g. /blog/awesome or /blog/awesome/1
 // 2. It's URL for tag list, e.g. /blog/awesome/#cat or /blog/awesome/#cat/2
+// If site wide tags is enabled, /tags and /tags/cat are reserved for it. The
+// same goes for custom taxonomies, e.g. /categories and /categories/cat.
+// Directory with archive enabled also has yearly and monthly archive, e.g.
+// /blog/2023 and /blog/2023/03.
 func (wk *Worker) Build(urlPath string, w io.Writer) ([]string, error) {
 	// Trim trailing slash and hash from URL path
 	for {
@@ -101,14 +159,30 @@ func (wk *Worker) Build(urlPath string, w io.Writer) ([]string, error) {
 		}
 	}
 
+	// Tag descriptions are not rendered on its own
+	for _, segment := range strings.Split(urlPath, "/") {
+		if segment == tagDescDir {
+			return nil, fmt.Errorf("%s is not part of site content", urlPath)
+		}
+	}
+
 	// Build page depending on URL path
 	var err error
 	var childURLs []string
 
 	switch {
+	case wk.isSiteTagsURL(urlPath):
+		childURLs, err = wk.buildSiteTags(urlPath, w)
+
+	case wk.isTaxonomyURL(urlPath):
+		childURLs, err = wk.buildTaxonomy(urlPath, w)
+
 	case rxTagURL.MatchString(urlPath):
 		childURLs, err = wk.buildTagFiles(urlPath, w)
 
+	case wk.isArchiveURL(urlPath):
+		childURLs, err = wk.buildArchive(urlPath, w)
+
 	case fileutils.IsFile(fp.Join(wk.ContentDir, urlPath+".md")):
 		err = wk.buildFile(urlPath, w)
 
@@ -119,140 +193,202 @@ func (wk *Worker) Build(urlPath string, w io.Writer) ([]string, error) {
 	return childURLs, err
 }
 
-// createTemplate creates HTML template from specified theme and template name.
-func (wk *Worker) renderHTML(w io.Writer, data interface{}, themeName string, templateName string) error {
-	// Check if template already cached
-	if wk.cacheEnabled {
-		combinedName := themeName + "-" + templateName
-		if tpl, exist := wk.templateCache[combinedName]; exist {
-			return tpl.Execute(w, data)
-		}
-	}
-
-	// Find theme dir
-	themeDir := fp.Join(wk.RootDir, "themes", themeName)
-
-	// If theme name not specified, use the first dir found
-	if themeName == "" && fileutils.IsDir(themeDir) {
-		dirItems, err := ioutil.ReadDir(themeDir)
+// renderHTML renders data into HTML using template from specified theme.
+// The template is looked up from the most specific section first, e.g. for
+// section `blog/2023` it will look for `blog/2023/file.html`, then
+// `blog/file.html` and finally `file.html`.
+func (wk *Worker) renderHTML(w io.Writer, data interface{}, themeName string, section string, templateName string) error {
+	// Get template, either from cache or by creating a new one
+	combinedName := themeName + "-" + section + "-" + templateName
+	tpl, cached := wk.templateCache[combinedName]
+	if !cached || !wk.cacheEnabled {
+		var err error
+		tpl, err = wk.createTemplate(themeName, section, templateName)
 		if err != nil {
 			return err
 		}
 
-		for _, item := range dirItems {
-			if item.IsDir() {
-				themeDir = fp.Join(themeDir, item.Name())
-				break
-			}
+		if wk.cacheEnabled {
+			wk.templateCache[combinedName] = tpl
 		}
 	}
 
-	// Get all HTML files in theme dir
-	dirItems, err := ioutil.ReadDir(themeDir)
+	// The cached template is shared, so execute its clone instead
+	tpl, err := wk.cloneTemplate(tpl)
 	if err != nil {
 		return err
 	}
 
-	// Separate base template and the others
+	// Execute template
+	var output io.Writer
+	if wk.minifyOutput {
+		output = wk.minifier.Writer("text/html", w)
+	} else {
+		output = w
+	}
+
+	err = tpl.Execute(output, data)
+	if err != nil {
+		return err
+	}
+
+	if wc, ok := output.(io.WriteCloser); ok {
+		if err = wc.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createTemplate creates HTML template from specified theme and template name.
+// Beside the page template, all HTML files in theme's root dir and in
+// `partials` dir will be loaded as well. Template in theme's root dir is named
+// by its file name, while template in `partials` dir is named by its path
+// relative to `partials` dir.
+func (wk *Worker) createTemplate(themeName string, section string, templateName string) (*template.Template, error) {
+	// Load theme along with its ancestors
+	theme, err := LoadTheme(wk.RootDir, themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the most specific page template
 	templateName += ".html"
-	baseTemplate := fp.Join(themeDir, templateName)
-	templateFiles := []string{baseTemplate}
+	templatePath := findSectionTemplate(theme, section, templateName)
+	if templatePath == "" {
+		return nil, fmt.Errorf("template %s doesn't exist in theme %s", templateName, theme.Name)
+	}
+
+	// Get all HTML files in theme's root dir, except the page template
+	templateFiles := []string{}
+	dirItems, err := theme.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
 
 	for _, item := range dirItems {
 		name := item.Name()
 		switch {
 		case item.IsDir(),
 			fp.Ext(name) != ".html",
-			name == templateName+".html":
+			name == templateName:
 			continue
 		}
 
-		templateFiles = append(templateFiles, fp.Join(themeDir, name))
+		templateFiles = append(templateFiles, name)
 	}
 
-	// Create and execute template
-	tpl, err := template.New(templateName).Funcs(wk.funcMap()).ParseFiles(templateFiles...)
-	if err != nil {
-		return err
+	// Parse the other templates first, so the page template is the last one
+	// that parsed. This way blocks defined in page template will not be
+	// overriden by the other templates.
+	tpl := template.New(templateName).Funcs(wk.funcMap())
+	for _, templateFile := range templateFiles {
+		err = parseThemeTemplate(tpl.New(templateFile), theme, templateFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var output io.Writer
-	if wk.minifyOutput {
-		output = wk.minifier.Writer("text/html", w)
-	} else {
-		output = w
+	err = parseThemeDir(tpl, theme, "partials")
+	if err != nil {
+		return nil, err
 	}
 
-	err = tpl.Execute(output, data)
+	err = parseThemeTemplate(tpl, theme, templatePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if wc, ok := output.(io.WriteCloser); ok {
-		if err = wc.Close(); err != nil {
-			return err
+	return tpl, nil
+}
+
+// findSectionTemplate looks for the most specific template for a section, then
+// returns its path within the theme. If template not found, returns empty string.
+func findSectionTemplate(theme Theme, section string, templateName string) string {
+	section = path.Clean("/" + section)
+	for {
+		templatePath := strings.TrimPrefix(path.Join(section, templateName), "/")
+		if _, err := fs.Stat(theme, templatePath); err == nil {
+			return templatePath
+		}
+
+		if section == "/" {
+			return ""
 		}
+
+		section = path.Dir(section)
 	}
+}
 
-	// Save to cache
-	if wk.cacheEnabled {
-		combinedName := themeName + "-" + templateName
-		wk.templateCache[combinedName] = tpl
+// parseThemeTemplate parses content of template file in theme into tpl.
+func parseThemeTemplate(tpl *template.Template, theme Theme, templatePath string) error {
+	content, err := fs.ReadFile(theme, templatePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = tpl.Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", templatePath, err)
 	}
 
 	return nil
 }
 
+// parseThemeDir parses every HTML files inside dir of the theme into tpl. Each
+// template is named by its path relative to the dir. If the dir doesn't exist,
+// nothing will be parsed.
+func parseThemeDir(tpl *template.Template, theme Theme, dir string) error {
+	return fs.WalkDir(theme, dir, func(fPath string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && fPath == dir {
+			return fs.SkipDir
+		}
+
+		if err != nil || d.IsDir() || path.Ext(fPath) != ".html" {
+			return err
+		}
+
+		name := strings.TrimPrefix(fPath, dir+"/")
+		return parseThemeTemplate(tpl.New(name), theme, fPath)
+	})
+}
+
 // parsePath parse markdown file in specified path. It's like `parseMarkdown`
 // method, but here we also do caching and look up to parent's metadata to fill
 // missing metadata in current path.
-func (wk *Worker) parsePath(path string) (meta model.Metadata, htmlContent template.HTML, err error) {
+func (wk *Worker) parsePath(path string) (meta model.Metadata, content renderedContent, err error) {
 	// Check if this path already cached
 	if wk.cacheEnabled {
-		var metaExist, htmlExist bool
+		var metaExist, contentExist bool
 
 		meta, metaExist = wk.metaCache[path]
-		htmlContent, htmlExist = wk.htmlCache[path]
-		if metaExist && htmlExist {
+		content, contentExist = wk.contentCache[path]
+		if metaExist && contentExist {
 			return
 		}
 	}
 
 	// Capture metadata from markdown file.
-	meta, htmlContent, _ = wk.parseMarkdown(path)
+	meta, mdContent, _ := wk.parseMarkdown(path)
 
 	// If title is empty, use fallback title
 	if meta.Title == "" {
-		if base := fp.Base(path); base == "_index.md" {
-			meta.Title = fp.Base(fp.Dir(path))
-		} else {
-			meta.Title = strings.TrimSuffix(base, ".md")
-		}
+		meta.Title = fallbackTitle(path)
 	}
 
 	// Sometimes user might not fill nor create the metadata.
-	// In this case, looks for parent's metadata.
-	metaIsReady := func() bool {
-		return meta.Theme != "" &&
-			meta.DirTemplate != "" &&
-			meta.FileTemplate != "" &&
-			meta.TagFilesTemplate != "" &&
-			meta.Pagination != 0
-	}
-
+	// In this case, looks for parent's metadata. Since empty value is valid
+	// for some fields like highlight style, we can't tell whether the meta
+	// is complete, so every parent is checked.
 	for parent := fp.Dir(path); parent != wk.RootDir; parent = fp.Dir(parent) {
-		// If all important meta is ready, stop
-		if metaIsReady() {
-			break
-		}
-
 		// Get parent metadata
 		parentIndex := fp.Join(parent, "_index.md")
 		if !fileutils.IsFile(parentIndex) {
 			continue
 		}
 
-		parentMeta, _, parentErr := wk.parseMarkdown(parentIndex)
+		parentMeta, parentErr := wk.rawMeta(parentIndex)
 		if parentErr != nil {
 			err = parentErr
 			return
@@ -275,23 +411,81 @@ func (wk *Worker) parsePath(path string) (meta model.Metadata, htmlContent templ
 			meta.TagFilesTemplate = parentMeta.TagFilesTemplate
 		}
 
+		if meta.TagsTemplate == "" {
+			meta.TagsTemplate = parentMeta.TagsTemplate
+		}
+
+		if meta.ArchiveTemplate == "" {
+			meta.ArchiveTemplate = parentMeta.ArchiveTemplate
+		}
+
 		if meta.Pagination == 0 {
 			meta.Pagination = parentMeta.Pagination
 		}
+
+		if meta.SummaryLength == 0 {
+			meta.SummaryLength = parentMeta.SummaryLength
+		}
+
+		// Sort order only make sense along with its key
+		if meta.SortBy == "" {
+			meta.SortBy = parentMeta.SortBy
+			meta.SortOrder = parentMeta.SortOrder
+		}
+
+		inheritMarkdownConfig(&meta.Markdown, parentMeta.Markdown)
+	}
+
+	// If this path is being rendered, e.g. its content has shortcode that
+	// lists pages in its own dir, only return the metadata to prevent
+	// infinite recursion
+	if _, isRendering := wk.rendering[path]; isRendering {
+		return
+	}
+
+	// Now that the theme is known, render the markdown content
+	content, err = wk.renderingWorker(path).renderMarkdown(mdContent, path, meta)
+	if err != nil {
+		return
 	}
 
 	// Save to cache
 	if wk.cacheEnabled {
 		wk.metaCache[path] = meta
-		wk.htmlCache[path] = htmlContent
+		wk.contentCache[path] = content
 	}
 
 	return
 }
 
+// fallbackTitle returns title for markdown file that doesn't have it, which
+// is the name of the file, or name of the directory for `_index.md`.
+func fallbackTitle(mdPath string) string {
+	base := fp.Base(mdPath)
+	if base == "_index.md" {
+		return fp.Base(fp.Dir(mdPath))
+	}
+
+	return strings.TrimSuffix(base, ".md")
+}
+
+// renderingWorker returns copy of the worker that marks the path as being
+// rendered. Template functions that called while rendering the path are bound
+// to this copy, so the mark is only visible to its own rendering.
+func (wk Worker) renderingWorker(path string) *Worker {
+	rendering := make(map[string]struct{}, len(wk.rendering)+1)
+	for renderedPath := range wk.rendering {
+		rendering[renderedPath] = struct{}{}
+	}
+
+	rendering[path] = struct{}{}
+	wk.rendering = rendering
+	return &wk
+}
+
 // parseMarkdown parse markdown file in specified path. It will splits between
-// metadata and content.
-func (wk *Worker) parseMarkdown(mdPath string) (meta model.Metadata, htmlContent template.HTML, err error) {
+// metadata and markdown content.
+func (wk *Worker) parseMarkdown(mdPath string) (meta model.Metadata, mdContent []byte, err error) {
 	// Open file
 	f, err := os.Open(mdPath)
 	if err != nil {
@@ -322,17 +516,150 @@ func (wk *Worker) parseMarkdown(mdPath string) (meta model.Metadata, htmlContent
 	}
 
 	// Parse metadata
-	err = toml.NewDecoder(metaBuffer).Decode(&meta)
+	frontMatter, err := toml.LoadBytes(metaBuffer.Bytes())
 	if err != nil {
 		return
 	}
 
-	// Parse markdown
-	btHTML, err := convertMarkdownToHTML(contentBuffer.Bytes())
+	err = frontMatter.Unmarshal(&meta)
 	if err != nil {
 		return
 	}
 
-	htmlContent = template.HTML(btHTML)
+	meta.FrontMatter = frontMatter.ToMap()
+
+	mdContent = contentBuffer.Bytes()
 	return
 }
+
+// renderMarkdown converts markdown content of file in specified path into HTML,
+// then creates its summary. The summary is taken from metadata, from content
+// before summary divider or from the first words of content, in that order.
+func (wk *Worker) renderMarkdown(mdContent []byte, mdPath string, meta model.Metadata) (renderedContent, error) {
+	// Separate summary from content
+	mdSummary, mdContent := splitSummary(mdContent)
+	if meta.Summary != "" {
+		mdSummary = []byte(meta.Summary)
+	}
+
+	// Convert the content
+	result, err := wk.convertPageMarkdown(mdContent, mdPath, meta, false)
+	if err != nil {
+		return renderedContent{}, err
+	}
+
+	// Record raw HTML that removed from this file. It's only reported when
+	// building the site, so skip it when cache is disabled, e.g. in webserver
+	// where the worker is shared by concurrent requests.
+	if wk.cacheEnabled && len(result.RemovedHTML) > 0 {
+		wk.removedHTML[mdPath] = result.RemovedHTML
+	}
+
+	text := plainText(result.HTML)
+	content := renderedContent{
+		HTML:      template.HTML(result.HTML),
+		WordCount: len(strings.Fields(text)),
+	}
+	content.ReadingTime = readingTime(content.WordCount)
+
+	// If the table of contents already rendered inline by `[[toc]]` marker,
+	// leave it empty so the theme doesn't render it twice
+	if !result.InlineTOC {
+		content.TOC = result.TOC
+	}
+
+	// Create the summary. It's shown outside of its page, so it's converted
+	// without heading IDs and table of contents.
+	if mdSummary != nil {
+		summaryResult, err := wk.convertPageMarkdown(mdSummary, mdPath, meta, true)
+		if err != nil {
+			return renderedContent{}, err
+		}
+		content.Summary = template.HTML(summaryResult.HTML)
+	} else {
+		summaryLength := meta.SummaryLength
+		if summaryLength <= 0 {
+			summaryLength = defaultSummaryLength
+		}
+		content.Summary = summarizeText(text, summaryLength)
+	}
+
+	return content, nil
+}
+
+// convertPageMarkdown converts markdown of file in specified path into HTML.
+// If the theme has render hook templates and shortcodes, they will be used
+// as well. If it's a summary, headings don't have ID and `[[toc]]` marker
+// is removed.
+func (wk *Worker) convertPageMarkdown(mdContent []byte, mdPath string, meta model.Metadata, isSummary bool) (markdownResult, error) {
+	hookTemplate, err := wk.renderHookTemplate(meta.Theme)
+	if err != nil {
+		return markdownResult{}, err
+	}
+
+	var hooks *hookRenderer
+	if hookTemplate != nil {
+		hooks = &hookRenderer{
+			template: hookTemplate,
+			page: model.PageInfo{
+				URLPath:  wk.urlPathOf(mdPath),
+				Metadata: meta,
+			},
+		}
+	}
+
+	// Render the shortcodes before converting markdown, so their output
+	// will not be processed by markdown converter
+	var shortcodes *shortcodeResolver
+	if bytes.Contains(mdContent, []byte("{{<")) {
+		shortcodeTemplate, err := wk.shortcodeTemplate(meta.Theme)
+		if err != nil {
+			return markdownResult{}, err
+		}
+
+		shortcodes = &shortcodeResolver{
+			template:  shortcodeTemplate,
+			themeName: meta.Theme,
+			page:      model.PageInfo{URLPath: wk.urlPathOf(mdPath), Metadata: meta},
+			cfg:       meta.Markdown,
+			hooks:     hooks,
+			isSummary: isSummary,
+		}
+
+		mdContent, err = shortcodes.resolve(mdContent)
+		if err != nil {
+			return markdownResult{}, fmt.Errorf("%s: %w", mdPath, err)
+		}
+	}
+
+	result, err := convertMarkdownToHTML(mdContent, meta.Markdown, hooks, shortcodes, isSummary)
+	if err != nil {
+		return result, err
+	}
+
+	if shortcodes != nil {
+		result.HTML = shortcodes.restore(result.HTML, true)
+		result.RemovedHTML = mergeRemovedHTML(result.RemovedHTML, shortcodes.Removed())
+	}
+
+	return result, nil
+}
+
+// RemovedHTML returns raw HTML elements and attributes that removed from
+// markdown files, mapped by path of the file. It's only recorded when cache
+// is enabled.
+func (wk *Worker) RemovedHTML() map[string][]string {
+	return wk.removedHTML
+}
+
+// urlPathOf returns URL path of markdown file in specified path.
+func (wk *Worker) urlPathOf(mdPath string) string {
+	relPath, err := fp.Rel(wk.ContentDir, mdPath)
+	if err != nil {
+		return ""
+	}
+
+	relPath = strings.TrimSuffix(fp.ToSlash(relPath), ".md")
+	relPath = strings.TrimSuffix(relPath, "_index")
+	return path.Join("/", relPath)
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"io/fs"
 	"os"
 	fp "path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,6 +25,8 @@ func buildCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("output", "o", "", "path to output directory")
+	cmd.Flags().Bool("future", false, "build content whose publish time is in the future")
+	cmd.Flags().Bool("expired", false, "build content whose expiry time has passed")
 	return cmd
 }
 
@@ -45,6 +49,9 @@ func buildHandler(cmd *cobra.Command, args []string) {
 		outputDir = fp.Join(rootDir, "public")
 	}
 
+	buildFuture, _ := cmd.Flags().GetBool("future")
+	buildExpired, _ := cmd.Flags().GetBool("expired")
+
 	// Clean output dir, but keep CNAME file and dot dir
 	logrus.Println("cleaning output dir")
 	err = cleanOutputDir(outputDir)
@@ -62,7 +69,7 @@ func buildHandler(cmd *cobra.Command, args []string) {
 
 	// Build site content
 	logrus.Println("building site content")
-	err = buildContent(rootDir, outputDir)
+	err = buildContent(rootDir, outputDir, buildFuture, buildExpired)
 	panicError(err)
 
 	// Report build duration
@@ -196,101 +203,74 @@ func copyThemes(rootDir, outputDir string) error {
 		return os.RemoveAll(dstDir)
 	}
 
-	// Get list of excluded paths from each theme
-	excludedPaths := make(map[string]struct{})
+	// List all themes. Since a theme might inherit its parent, the files
+	// that copied for each theme is merged from all of its ancestors.
 	themeList, err := os.ReadDir(srcDir)
 	if err != nil {
 		return err
 	}
 
-	for _, theme := range themeList {
+	srcItems := map[string]string{".": ""}
+	for _, themeItem := range themeList {
 		// Valid theme must be a directory
-		if !theme.IsDir() {
+		if !themeItem.IsDir() {
 			continue
 		}
 
-		// If theme has boomignore file, parse it
-		themeDir := fp.Join(srcDir, theme.Name())
-		boomignorePath := fp.Join(themeDir, ".boomignore")
-
-		if fileutils.IsFile(boomignorePath) {
-			err = func() error {
-				// Open boomignore file
-				boomignore, err := os.Open(boomignorePath)
-				if err != nil {
-					return nil
-				}
-				defer boomignore.Close()
-
-				// Read each line
-				scanner := bufio.NewScanner(boomignore)
-				for scanner.Scan() {
-					line := strings.TrimSpace(scanner.Text())
-					if line == "" {
-						continue
-					}
-
-					currentPath := fp.Clean(fp.Join(themeDir, line))
-					excludedPaths[currentPath] = struct{}{}
-				}
-
-				return nil
-			}()
-
-			if err != nil {
-				return err
-			}
-
-			// Exclude boomignore file as well
-			excludedPaths[boomignorePath] = struct{}{}
-		}
-
-		// Read items in theme's root dir
-		themeItems, err := os.ReadDir(themeDir)
+		theme, err := build.LoadTheme(rootDir, themeItem.Name())
 		if err != nil {
 			return err
 		}
 
-		// Make sure to exclude :
-		// - dot dir (like .git)
-		// - node_modules dir
-		// - html file since it's only used in template
-		for _, item := range themeItems {
-			itemName := item.Name()
-			itemPath := fp.Join(themeDir, itemName)
-
-			switch {
-			case fp.Ext(itemName) == ".html",
-				item.IsDir() && itemName == "node_modules",
-				item.IsDir() && strings.HasPrefix(itemName, "."):
-				excludedPaths[itemPath] = struct{}{}
+		// Get list of excluded paths from each layer of theme
+		excludedPaths := make(map[string]struct{})
+		for _, layer := range theme.Layers {
+			err = excludeThemeLayerPaths(layer, excludedPaths)
+			if err != nil {
+				return err
 			}
 		}
-	}
 
-	// List all items in src and dst
-	srcItems := make(map[string]fs.DirEntry)
-	dstItems := make(map[string]fs.DirEntry)
+		// Walk the merged theme
+		err = fs.WalkDir(theme, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
 
-	fp.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+			// HTML files are only used in template, including the one in
+			// section dirs, so don't copy them
+			_, excluded := excludedPaths[path]
+			if excluded || (!d.IsDir() && fp.Ext(path) == ".html") {
+				if d.IsDir() {
+					return fs.SkipDir
+				} else {
+					return nil
+				}
+			}
 
-		_, excluded := excludedPaths[path]
-		if excluded {
+			// Directory doesn't have source path since it's not copied
+			relPath := fp.Join(theme.Name, fp.FromSlash(path))
 			if d.IsDir() {
-				return fp.SkipDir
-			} else {
+				srcItems[relPath] = ""
 				return nil
 			}
-		}
 
-		relPath, _ := fp.Rel(srcDir, path)
-		srcItems[relPath] = d
-		return nil
-	})
+			// Embedded default theme only contains templates, so every
+			// copied file must come from a theme dir
+			layer, _ := theme.Locate(path)
+			if layer.Dir != "" {
+				srcItems[relPath] = fp.Join(layer.Dir, fp.FromSlash(path))
+			}
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
 
+	// List all items in dst
+	dstItems := make(map[string]fs.DirEntry)
 	fp.WalkDir(dstDir, func(path string, d fs.DirEntry, err error) error {
 		if err == nil {
 			relPath, _ := fp.Rel(dstDir, path)
@@ -312,17 +292,14 @@ func copyThemes(rootDir, outputDir string) error {
 	}
 
 	// Copy files from src to dst
-	for srcItem, mode := range srcItems {
+	for srcItem, srcPath := range srcItems {
 		// Ignore directory
-		if mode.IsDir() {
+		if srcPath == "" {
 			continue
 		}
 
-		// Create file path
-		srcPath := fp.Join(srcDir, srcItem)
-		dstPath := fp.Join(dstDir, srcItem)
-
 		// If file is the same, continue
+		dstPath := fp.Join(dstDir, srcItem)
 		if fileutils.SameFile(srcPath, dstPath) {
 			continue
 		}
@@ -342,11 +319,59 @@ func copyThemes(rootDir, outputDir string) error {
 	return nil
 }
 
-func buildContent(rootDir, outputDir string) error {
+func excludeThemeLayerPaths(layer build.ThemeLayer, excludedPaths map[string]struct{}) error {
+	// Theme metadata is only used while building
+	excludedPaths["theme.toml"] = struct{}{}
+
+	// If theme has boomignore file, parse it
+	boomignore, err := fs.ReadFile(layer.FS, ".boomignore")
+	if err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(boomignore))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			currentPath := fp.ToSlash(fp.Clean(line))
+			excludedPaths[currentPath] = struct{}{}
+		}
+
+		// Exclude boomignore file as well
+		excludedPaths[".boomignore"] = struct{}{}
+	}
+
+	// Read items in theme's root dir
+	themeItems, err := fs.ReadDir(layer.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	// Make sure to exclude :
+	// - dot dir (like .git)
+	// - node_modules dir
+	// - partials dir since it's only used in template
+	for _, item := range themeItems {
+		itemName := item.Name()
+
+		switch {
+		case item.IsDir() && itemName == "partials",
+			item.IsDir() && itemName == "node_modules",
+			item.IsDir() && strings.HasPrefix(itemName, "."):
+			excludedPaths[itemName] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+func buildContent(rootDir, outputDir string, buildFuture, buildExpired bool) error {
 	// Create worker
 	cfg := build.Config{
 		EnableCache:  true,
 		BuildDraft:   false,
+		BuildFuture:  buildFuture,
+		BuildExpired: buildExpired,
 		MinifyOutput: true,
 	}
 
@@ -386,7 +411,9 @@ func buildContent(rootDir, outputDir string) error {
 		childURLs, err := wk.Build(urlPath, dstFile)
 		if err != nil {
 			os.Remove(dstPath)
-			if err != build.ErrDraftFile {
+			if err != build.ErrDraftFile &&
+				err != build.ErrUnpublishedFile &&
+				err != build.ErrHeadlessContent {
 				return err
 			}
 		}
@@ -406,5 +433,37 @@ func buildContent(rootDir, outputDir string) error {
 	}
 
 	// Build the content
-	return fnBuild("")
+	err = fnBuild("")
+	if err != nil {
+		return err
+	}
+
+	// Warn about raw HTML that removed from content
+	removedHTML := wk.RemovedHTML()
+	mdPaths := make([]string, 0, len(removedHTML))
+	for mdPath := range removedHTML {
+		mdPaths = append(mdPaths, mdPath)
+	}
+
+	sort.Strings(mdPaths)
+	for _, mdPath := range mdPaths {
+		relPath, _ := fp.Rel(rootDir, mdPath)
+		logrus.Warnf("raw HTML removed from %s: %s\n", relPath,
+			strings.Join(removedHTML[mdPath], ", "))
+	}
+
+	// Warn about different tags that share the same URL
+	tagCollisions := wk.TagCollisions()
+	slugs := make([]string, 0, len(tagCollisions))
+	for slug := range tagCollisions {
+		slugs = append(slugs, slug)
+	}
+
+	sort.Strings(slugs)
+	for _, slug := range slugs {
+		logrus.Warnf("tags %s share the same slug %q\n",
+			strings.Join(tagCollisions[slug], ", "), slug)
+	}
+
+	return nil
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	fp "path/filepath"
+
+	"github.com/RadhiFadlillah/boom/internal/build"
+	"github.com/spf13/cobra"
+)
+
+func listScheduledCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scheduled [root-path]",
+		Short: "List content whose publish time is in the future",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   listScheduledHandler,
+	}
+
+	return cmd
+}
+
+func listScheduledHandler(cmd *cobra.Command, args []string) {
+	// Parse args
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+
+	rootDir, err := fp.Abs(rootDir)
+	panicError(err)
+
+	// Create worker
+	wk, err := build.NewWorker(rootDir, build.Config{})
+	panicError(err)
+
+	// Fetch and print the scheduled content
+	items, err := wk.ScheduledContent()
+	panicError(err)
+
+	if len(items) == 0 {
+		fmt.Println("No scheduled content")
+		return
+	}
+
+	for _, item := range items {
+		cBold.Printf("%s  ", item.PublishTime.Format("2006-01-02 15:04 MST"))
+		fmt.Printf("%s (%s)\n", item.URLPath, item.Title)
+	}
+}
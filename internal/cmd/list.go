@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+func listCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List content of the site",
+	}
+
+	cmd.AddCommand(listScheduledCmd())
+	return cmd
+}
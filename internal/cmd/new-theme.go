@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	fp "path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/spf13/cobra"
+)
+
+//go:embed all:theme-skeleton
+var themeSkeleton embed.FS
+
+func newThemeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "theme [name]",
+		Short: "Create a new theme skeleton with specified name",
+		Args:  cobra.ExactArgs(1),
+		Run:   newThemeHandler,
+	}
+
+	cmd.Flags().StringP("root", "r", ".", "path to root dir of the site")
+	return cmd
+}
+
+func newThemeHandler(cmd *cobra.Command, args []string) {
+	// Read arguments
+	name := strings.TrimSpace(args[0])
+	rootDir, _ := cmd.Flags().GetString("root")
+
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		cError.Println("Theme name must be a valid directory name")
+		return
+	}
+
+	// Make sure the theme doesn't exist yet
+	themeDir := fp.Join(rootDir, "themes", name)
+	if fileutils.IsDir(themeDir) {
+		cError.Printf("Theme %s already exists\n", name)
+		return
+	}
+
+	// Write each file in skeleton. Since the theme files are Go templates as
+	// well, here we use different delimiters to fill the skeleton.
+	skeleton, _ := fs.Sub(themeSkeleton, "theme-skeleton")
+	skeletonData := struct{ Name string }{Name: name}
+
+	prefixErr := "Failed to create theme:"
+	err := fs.WalkDir(skeleton, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dstPath := fp.Join(themeDir, fp.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, os.ModePerm)
+		}
+
+		content, err := fs.ReadFile(skeleton, path)
+		if err != nil {
+			return err
+		}
+
+		tpl, err := template.New(path).Delims("[[", "]]").Parse(string(content))
+		if err != nil {
+			return err
+		}
+
+		dstFile, err := os.Create(dstPath)
+		if err != nil {
+			return err
+		}
+		defer dstFile.Close()
+
+		return tpl.Execute(dstFile, skeletonData)
+	})
+	panicError(err, prefixErr)
+
+	// Finish
+	fmt.Print("Your new theme is created in ")
+	cBold.Println(themeDir)
+}
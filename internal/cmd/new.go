@@ -5,9 +5,9 @@ import "github.com/spf13/cobra"
 func newCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "new",
-		Short: "Create a new site or metadata",
+		Short: "Create a new site, metadata or theme",
 	}
 
-	cmd.AddCommand(newSiteCmd(), newMetaCmd())
+	cmd.AddCommand(newSiteCmd(), newMetaCmd(), newThemeCmd())
 	return cmd
 }
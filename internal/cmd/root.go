@@ -17,6 +17,6 @@ func BoomCmd() *cobra.Command {
 		Short: "Simple static site generator",
 	}
 
-	cmd.AddCommand(newCmd(), serveCmd(), buildCmd())
+	cmd.AddCommand(newCmd(), serveCmd(), buildCmd(), themeCmd(), listCmd())
 	return cmd
 }
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	fp "path/filepath"
+
+	"github.com/RadhiFadlillah/boom/internal/build"
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/spf13/cobra"
+)
+
+func themeCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [root-path]",
+		Short: "Check templates of every theme in the site",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   themeCheckHandler,
+	}
+
+	return cmd
+}
+
+func themeCheckHandler(cmd *cobra.Command, args []string) {
+	// Parse args
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+
+	rootDir, err := fp.Abs(rootDir)
+	panicError(err)
+
+	// Create worker
+	wk, err := build.NewWorker(rootDir, build.Config{BuildDraft: true})
+	panicError(err)
+
+	// List all themes. If default theme is not ejected, check it as well.
+	themeNames := []string{}
+	themesDir := fp.Join(rootDir, "themes")
+	if fileutils.IsDir(themesDir) {
+		themeItems, err := os.ReadDir(themesDir)
+		panicError(err)
+
+		for _, item := range themeItems {
+			if item.IsDir() {
+				themeNames = append(themeNames, item.Name())
+			}
+		}
+	}
+
+	if !fileutils.IsDir(fp.Join(themesDir, build.DefaultThemeName)) {
+		themeNames = append(themeNames, build.DefaultThemeName)
+	}
+
+	// Check each theme
+	nProblems := 0
+	for _, themeName := range themeNames {
+		problems, err := wk.CheckTheme(themeName)
+		if err != nil {
+			nProblems++
+			cBold.Printf("%s: ", themeName)
+			cError.Println(err)
+			continue
+		}
+
+		for _, problem := range problems {
+			cBold.Printf("%s: ", themeName)
+			cError.Println(problem)
+		}
+
+		if len(problems) == 0 {
+			cBold.Printf("%s: ", themeName)
+			fmt.Println("ok")
+		}
+
+		nProblems += len(problems)
+	}
+
+	if nProblems > 0 {
+		cError.Printf("Found %d problems\n", nProblems)
+		os.Exit(1)
+	}
+}
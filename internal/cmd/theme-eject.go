@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	fp "path/filepath"
+	"strings"
+
+	"github.com/RadhiFadlillah/boom/internal/build"
+	"github.com/RadhiFadlillah/boom/internal/fileutils"
+	"github.com/spf13/cobra"
+)
+
+func themeEjectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eject [root-path]",
+		Short: "Copy the embedded default theme into themes dir",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   themeEjectHandler,
+	}
+
+	cmd.Flags().StringP("name", "n", build.DefaultThemeName, "name of the ejected theme")
+	return cmd
+}
+
+func themeEjectHandler(cmd *cobra.Command, args []string) {
+	// Read arguments
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		cError.Println("Theme name must not empty")
+		return
+	}
+
+	// Make sure the theme doesn't exist yet
+	themeDir := fp.Join(rootDir, "themes", name)
+	if fileutils.IsDir(themeDir) {
+		cError.Printf("Theme %s already exists\n", name)
+		return
+	}
+
+	// Copy the default theme
+	err := fileutils.CopyFS(build.DefaultThemeFS(), themeDir)
+	panicError(err, "Failed to eject default theme:")
+
+	// Finish
+	fmt.Print("Default theme is ejected to ")
+	cBold.Println(themeDir)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/RadhiFadlillah/boom/internal/build"
+	"github.com/spf13/cobra"
+)
+
+func themeFunctionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "functions",
+		Short: "Print reference of functions that available in template",
+		Args:  cobra.NoArgs,
+		Run:   themeFunctionsHandler,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "write the reference into this file")
+	return cmd
+}
+
+func themeFunctionsHandler(cmd *cobra.Command, args []string) {
+	// Parse flags
+	output, _ := cmd.Flags().GetString("output")
+
+	// Print or save the reference
+	reference := build.FunctionReference()
+	if output == "" {
+		fmt.Print(reference)
+		return
+	}
+
+	err := os.WriteFile(output, []byte(reference), 0644)
+	panicError(err)
+}
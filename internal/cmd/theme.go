@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+func themeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "theme",
+		Short: "Manage themes of the site",
+	}
+
+	cmd.AddCommand(themeEjectCmd(), themeCheckCmd(), themeFunctionsCmd())
+	return cmd
+}
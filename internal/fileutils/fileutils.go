@@ -3,6 +3,7 @@ package fileutils
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	fp "path/filepath"
@@ -198,3 +199,37 @@ func CopyDir(src string, dst string, excludedPaths map[string]struct{}) (err err
 
 	return
 }
+
+// CopyFS recursively copies the entire file system into dst directory.
+// Destination directory must *not* exist.
+func CopyFS(fsys fs.FS, dst string) error {
+	// Make sure destination is not exist
+	dst = fp.Clean(dst)
+	_, err := os.Stat(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil {
+		return fmt.Errorf("destination already exists")
+	}
+
+	// Copy each entry
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dstPath := fp.Join(dst, fp.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, os.ModePerm)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(dstPath, content, 0644)
+	})
+}
@@ -13,15 +13,84 @@ type Metadata struct {
 	Author      string    `toml:",omitempty"`
 	CreateTime  time.Time `toml:",omitempty"`
 	UpdateTime  time.Time `toml:",omitempty"`
+	PublishTime time.Time `toml:",omitempty"`
+	ExpiryTime  time.Time `toml:",omitempty"`
 	Tags        []string  `toml:",omitempty"`
 	Draft       bool      `toml:",omitempty"`
+	Hidden      bool      `toml:",omitempty"`
+	Headless    bool      `toml:",omitempty"`
+	Summary     string    `toml:",omitempty"`
+	Cover       string    `toml:",omitempty"`
+	Weight      int       `toml:",omitempty"`
+	Series      string    `toml:",omitempty"`
+
+	// Custom metadatas, free to be used by theme
+	Params map[string]interface{} `toml:",omitempty"`
 
 	// Theme's metadatas
 	Theme            string `toml:",omitempty"`
 	DirTemplate      string `toml:",omitempty"`
 	FileTemplate     string `toml:",omitempty"`
 	TagFilesTemplate string `toml:",omitempty"`
+	TagsTemplate     string `toml:",omitempty"`
+	ArchiveTemplate  string `toml:",omitempty"`
 	Pagination       int    `toml:",omitempty"`
+	SummaryLength    int    `toml:",omitempty"`
+	SortBy           string `toml:",omitempty"`
+	SortOrder        string `toml:",omitempty"`
+	SiteTags         bool   `toml:",omitempty"`
+	Archive          bool   `toml:",omitempty"`
+
+	// Tag aliases, mapping alias to its canonical tag
+	TagAliases map[string]string `toml:",omitempty"`
+
+	// Markdown rendering options
+	Markdown MarkdownConfig `toml:",omitempty"`
+
+	// Site wide taxonomies beside tags
+	Taxonomies []TaxonomyConfig `toml:",omitempty"`
+
+	// Raw front matter, used for looking up the taxonomy terms
+	FrontMatter map[string]interface{} `toml:"-"`
+}
+
+// MarkdownConfig is configuration for rendering markdown content. Nil or empty
+// field will be inherited from parent's metadata, or use the default value.
+type MarkdownConfig struct {
+	Extensions     []string `toml:",omitempty"`
+	HardWraps      *bool    `toml:",omitempty"`
+	XHTML          *bool    `toml:",omitempty"`
+	Typographer    *bool    `toml:",omitempty"`
+	Attributes     *bool    `toml:",omitempty"`
+	HeadingID      string   `toml:",omitempty"`
+	HighlightStyle string   `toml:",omitempty"`
+	LineNumbers    *bool    `toml:",omitempty"`
+	Admonitions    []string `toml:",omitempty"`
+	TOCStartLevel  int      `toml:",omitempty"`
+	TOCEndLevel    int      `toml:",omitempty"`
+
+	RawHTML           string   `toml:",omitempty"`
+	AllowedElements   []string `toml:",omitempty"`
+	AllowedAttributes []string `toml:",omitempty"`
+}
+
+// TaxonomyConfig is configuration for a custom taxonomy like categories or
+// series. Empty field will use its default value.
+type TaxonomyConfig struct {
+	Name              string `toml:",omitempty"`
+	Key               string `toml:",omitempty"`
+	URLPrefix         string `toml:",omitempty"`
+	Title             string `toml:",omitempty"`
+	TermsTemplate     string `toml:",omitempty"`
+	TermFilesTemplate string `toml:",omitempty"`
+	Pagination        int    `toml:",omitempty"`
+	SortBy            string `toml:",omitempty"`
+	SortOrder         string `toml:",omitempty"`
+}
+
+// ThemeMetadata is metadata of a theme, stored in `theme.toml` inside the theme dir.
+type ThemeMetadata struct {
+	Parent string `toml:",omitempty"`
 }
 
 // DirData is data that used when rendering a directory.
@@ -32,16 +101,23 @@ type DirData struct {
 	Title       string
 	Description string
 	Author      string
+	Cover       string
+	Params      map[string]interface{}
 	Content     template.HTML
+	TOC         []TOCItem
 	ChildItems  []ContentPath
 	ChildTags   []TagPath
+	TagTree     []TagPath
+	Archive     []ArchiveGroup
 
 	PageSize    int
 	CurrentPage int
 	MaxPage     int
 }
 
-// FileData is data that used when rendering a file.
+// FileData is data that used when rendering a file. PrevFile and NextFile
+// follow the sort order of its directory, while SeriesPrev and SeriesNext
+// follow the order of its series across the site.
 type FileData struct {
 	URLPath    string
 	PathTrails []ContentPath
@@ -51,19 +127,103 @@ type FileData struct {
 	Author      string
 	CreateTime  time.Time
 	UpdateTime  time.Time
+	Cover       string
+	Params      map[string]interface{}
 	Content     template.HTML
+	TOC         []TOCItem
+	Summary     template.HTML
+	WordCount   int
+	ReadingTime int
 
 	Tags     []TagPath
+	Terms    map[string][]TagPath
 	PrevFile ContentPath
 	NextFile ContentPath
+
+	Series         string
+	SeriesItems    []ContentPath
+	SeriesPosition int
+	SeriesPrev     ContentPath
+	SeriesNext     ContentPath
 }
 
 // TagFilesData is template model for rendering a tag file list.
+// Description and Content are taken from the tag's description file
+// in `_tags` directory, if it exists.
 type TagFilesData struct {
 	URLPath    string
 	PathTrails []ContentPath
 	ActiveTag  string
 
+	Title       string
+	Description string
+	Content     template.HTML
+	Files       []ContentPath
+	PageSize    int
+	CurrentPage int
+	MaxPage     int
+}
+
+// ArchiveData is template model for rendering files that created within a
+// year or a month. For yearly archive, Month is zero and the files in the
+// current page are grouped by its month in Months.
+type ArchiveData struct {
+	URLPath    string
+	PathTrails []ContentPath
+
+	Title      string
+	Period     string
+	Year       int
+	Month      int
+	Months     []ArchiveGroup
+	Files      []ContentPath
+	PrevPeriod ContentPath
+	NextPeriod ContentPath
+
+	PageSize    int
+	CurrentPage int
+	MaxPage     int
+}
+
+// ArchiveGroup is group of files that created within the same year or month.
+// Group for a year also contains groups for each of its month.
+type ArchiveGroup struct {
+	URLPath string
+	Title   string
+	Year    int
+	Month   int
+	Files   []ContentPath
+	Months  []ArchiveGroup
+}
+
+// TagsData is template model for rendering list of tags in the entire site.
+type TagsData struct {
+	URLPath    string
+	PathTrails []ContentPath
+
+	Title   string
+	Tags    []TagPath
+	TagTree []TagPath
+}
+
+// TermsData is template model for rendering list of terms in a taxonomy.
+type TermsData struct {
+	URLPath    string
+	PathTrails []ContentPath
+	Taxonomy   string
+
+	Title string
+	Terms []TagPath
+}
+
+// TermFilesData is template model for rendering list of files that use
+// a taxonomy term.
+type TermFilesData struct {
+	URLPath    string
+	PathTrails []ContentPath
+	Taxonomy   string
+	ActiveTerm string
+
 	Title       string
 	Files       []ContentPath
 	PageSize    int
@@ -74,20 +234,115 @@ type TagFilesData struct {
 // ContentPath is path to a content.
 type ContentPath struct {
 	// Common
-	IsDir   bool
-	URLPath string
-	Title   string
+	IsDir       bool
+	URLPath     string
+	Title       string
+	Description string
+	Author      string
+	CreateTime  time.Time
+	UpdateTime  time.Time
+	PublishTime time.Time
+	ExpiryTime  time.Time
+	Tags        []TagPath
+	Cover       string
+	Params      map[string]interface{}
+	Draft       bool
+	Hidden      bool
+	Headless    bool
+	Weight      int
+	Series      string
 
 	// File only
-	UpdateTime time.Time
+	Summary     template.HTML
+	WordCount   int
+	ReadingTime int
 
 	// Dir only
 	NChild int
 }
 
-// TagPath is path to a tag files.
+// TagPath is path to a tag files. For hierarchical tag like `lang/go`,
+// Parent is the tag above it and Children are the tags below it.
 type TagPath struct {
+	URLPath  string
+	Name     string
+	Count    int
+	Parent   *TagPath
+	Children []TagPath
+}
+
+// PageGroup is list of pages that grouped by the same key, e.g. its year.
+type PageGroup struct {
+	Key   string
+	Pages []ContentPath
+}
+
+// TOCItem is a heading in table of contents, along with its subheadings.
+type TOCItem struct {
+	Level    int
+	ID       string
+	Text     string
+	Children []TOCItem
+}
+
+// PageInfo is information of the page whose markdown content is being rendered.
+type PageInfo struct {
 	URLPath string
-	Name    string
-	Count   int
+	Metadata
+}
+
+// LinkHookData is data that used when rendering link in markdown content
+// using `render-link.html` template.
+type LinkHookData struct {
+	Page        PageInfo
+	Destination string
+	Title       string
+	Text        template.HTML
+	PlainText   string
+	Attributes  map[string]string
+}
+
+// ImageHookData is data that used when rendering image in markdown content
+// using `render-image.html` template.
+type ImageHookData struct {
+	Page        PageInfo
+	Destination string
+	Title       string
+	Text        string
+	Attributes  map[string]string
+}
+
+// HeadingHookData is data that used when rendering heading in markdown
+// content using `render-heading.html` template.
+type HeadingHookData struct {
+	Page       PageInfo
+	Level      int
+	ID         string
+	Text       template.HTML
+	PlainText  string
+	Attributes map[string]string
+}
+
+// CodeBlockHookData is data that used when rendering fenced code block in
+// markdown content using `render-codeblock-<lang>.html` template.
+type CodeBlockHookData struct {
+	Page       PageInfo
+	Language   string
+	Code       string
+	Attributes map[string]string
+}
+
+// ShortcodeData is data that used when rendering shortcode in markdown content
+// using template in `shortcodes` dir. Named arguments are stored in Params,
+// while positional arguments are stored in Args. For shortcode that has closing
+// tag, Inner is the raw content between its tags while InnerHTML is the content
+// rendered as markdown.
+type ShortcodeData struct {
+	Page      PageInfo
+	Name      string
+	Params    map[string]string
+	Args      []string
+	Inner     string
+	InnerHTML template.HTML
+	Parent    *ShortcodeData
 }
@@ -1,6 +1,9 @@
 package webserver
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	fp "path/filepath"
 	"strings"
@@ -18,6 +21,8 @@ func newHandler(rootDir string) (Handler, error) {
 	cfg := build.Config{
 		EnableCache:  false,
 		BuildDraft:   true,
+		BuildFuture:  true,
+		BuildExpired: true,
 		MinifyOutput: false,
 	}
 
@@ -39,15 +44,61 @@ func (hdl *Handler) serveSite(w http.ResponseWriter, r *http.Request, ps httprou
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 
-	// If it's for assets and themes, just serve it directly
-	if len(pathSegments) > 0 && (pathSegments[0] == "assets" || pathSegments[0] == "themes") {
+	// If it's for assets, just serve it directly
+	if len(pathSegments) > 0 && pathSegments[0] == "assets" {
 		staticPath := fp.Join(hdl.Worker.RootDir, urlPath)
 		http.ServeFile(w, r, staticPath)
 		return
 	}
 
+	// If it's for themes, serve it from the theme or its ancestors
+	if len(pathSegments) > 1 && pathSegments[0] == "themes" {
+		theme, err := build.LoadTheme(hdl.Worker.RootDir, pathSegments[1])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		prefix := "/themes/" + pathSegments[1]
+		http.StripPrefix(prefix, http.FileServer(http.FS(theme))).ServeHTTP(w, r)
+		return
+	}
+
 	// If not, it must be content that need to be build
+	buffer := bytes.NewBuffer(nil)
+	_, err := hdl.Build(urlPath, buffer)
+	if errors.Is(err, build.ErrNotFound) || errors.Is(err, build.ErrHeadlessContent) {
+		http.NotFound(w, r)
+		return
+	}
+	panicError(err)
+
+	// If content is scheduled or expired, mark it with badge
+	output := buffer.Bytes()
+	if status := hdl.PublishStatus(urlPath); status != build.StatusPublished {
+		output = insertBadge(output, status)
+	}
+
 	w.Header().Set("Content-Type", "text/html")
-	_, err := hdl.Build(urlPath, w)
+	_, err = w.Write(output)
 	panicError(err)
 }
+
+// insertBadge inserts a badge that shows the publish status into the end of
+// HTML body, so user knows the page won't be built in the published site.
+func insertBadge(output []byte, status string) []byte {
+	badge := fmt.Sprintf(`<div style="position:fixed;top:8px;right:8px;z-index:9999;`+
+		`padding:4px 8px;border-radius:4px;background:#d9534f;color:#fff;`+
+		`font:bold 12px sans-serif;text-transform:uppercase">%s</div>`, status)
+
+	idx := bytes.LastIndex(output, []byte("</body>"))
+	if idx < 0 {
+		return append(output, badge...)
+	}
+
+	result := make([]byte, 0, len(output)+len(badge))
+	result = append(result, output[:idx]...)
+	result = append(result, badge...)
+	result = append(result, output[idx:]...)
+	return result
+}